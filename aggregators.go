@@ -0,0 +1,232 @@
+package timeseries
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//bin is the rows [head, tail) of a `TimeSeries` being aggregated, end is where the bin closes
+type bin struct {
+	ts   TimeSeries
+	head int
+	tail int
+	end  time.Time
+}
+
+//values of a column inside the bin
+func (b bin) values(column string) []float64 {
+	return b.ts.Columns[column][b.head:b.tail]
+}
+
+//aggregator reduces a column over a bin to a single value
+type aggregator func(b bin, column string) float64
+
+//reducers are the builtin aggregators which only need the values of the column
+var reducers = map[string]func([]float64) float64{
+	"first": func(arr []float64) float64 {
+		return arr[0]
+	},
+	"last": func(arr []float64) float64 {
+		return arr[len(arr)-1]
+	},
+	"sum": func(arr []float64) float64 {
+		s := 0.0
+		for _, value := range arr {
+			s = s + value
+		}
+		return s
+	},
+	"max": func(arr []float64) float64 {
+		maximum := arr[0]
+		for _, value := range arr {
+			if value > maximum {
+				maximum = value
+			}
+		}
+		return maximum
+	},
+	"min": func(arr []float64) float64 {
+		minimum := arr[0]
+		for _, value := range arr {
+			if value < minimum {
+				minimum = value
+			}
+		}
+		return minimum
+	},
+	"mean": func(arr []float64) float64 {
+		return mean(arr)
+	},
+	"median": func(arr []float64) float64 {
+		return quantile(arr, 0.5)
+	},
+	"sd": func(arr []float64) float64 { //sample standard deviation
+		return math.Sqrt(variance(arr))
+	},
+	"var": func(arr []float64) float64 { //sample variance
+		return variance(arr)
+	},
+	"range": func(arr []float64) float64 {
+		sorted := append([]float64{}, arr...)
+		sort.Float64s(sorted)
+		return sorted[len(sorted)-1] - sorted[0]
+	},
+}
+
+var customReducers = make(map[string]func([]float64) float64)
+var customReducersLock sync.RWMutex
+
+//RegisterAggregator adds a named aggregator which can then be used in Resample criteria maps
+//fn receives the non NaN values of a bin and is not called for bins without any, those are NaN
+func RegisterAggregator(name string, fn func([]float64) float64) error {
+	if name == "" || strings.Contains(name, ":") {
+		return fmt.Errorf("could not register aggregator %s: invalid name", name)
+	}
+	if fn == nil {
+		return fmt.Errorf("could not register aggregator %s: nil function", name)
+	}
+	customReducersLock.Lock()
+	defer customReducersLock.Unlock()
+	_, custom := customReducers[name]
+	if builtin, err := builtinAggregator(name); builtin != nil || err != nil || custom {
+		return fmt.Errorf("could not register aggregator %s: name already in use", name)
+	}
+	customReducers[name] = fn
+	return nil
+}
+
+//lookupAggregator finds the aggregator for a criteria function name, builtin or registered
+func lookupAggregator(name string) (aggregator, error) {
+	if fn, err := builtinAggregator(name); fn != nil || err != nil {
+		return fn, err
+	}
+	customReducersLock.RLock()
+	defer customReducersLock.RUnlock()
+	if fn, ok := customReducers[name]; ok {
+		return skipNA(fn), nil
+	}
+	return nil, fmt.Errorf("no such field or function")
+}
+
+//builtinAggregator finds the builtin aggregator for a criteria function name, nil without error if there is none
+func builtinAggregator(name string) (aggregator, error) {
+	if fn, ok := reducers[name]; ok {
+		return skipNA(fn), nil
+	}
+	switch {
+	case name == "count":
+		return func(b bin, column string) float64 {
+			return float64(len(dropNA(b.values(column))))
+		}, nil
+	case name == "nunique":
+		return func(b bin, column string) float64 {
+			unique := make(map[float64]bool)
+			for _, v := range dropNA(b.values(column)) {
+				unique[v] = true
+			}
+			return float64(len(unique))
+		}, nil
+	case name == "twmean":
+		return timeWeightedMean, nil
+	case strings.HasPrefix(name, "wmean:"):
+		return weightedMean(strings.TrimPrefix(name, "wmean:")), nil
+	case strings.HasPrefix(name, "q") && isFloat(name[1:]):
+		q, _ := strconv.ParseFloat(name[1:], 64)
+		if !(q >= 0 && q <= 100) {
+			return nil, fmt.Errorf("quantile must be in [0, 100]")
+		}
+		return skipNA(func(arr []float64) float64 {
+			return quantile(arr, q/100)
+		}), nil
+	}
+	return nil, nil
+}
+
+//skipNA lifts a reducer into an aggregator which drops NaN values, bins without values are NaN
+func skipNA(fn func([]float64) float64) aggregator {
+	return func(b bin, column string) float64 {
+		arr := dropNA(b.values(column))
+		if len(arr) == 0 {
+			return math.NaN()
+		}
+		return fn(arr)
+	}
+}
+
+//weightedMean averages a column weighted by another column, rows where either is NaN are skipped
+func weightedMean(weightColumn string) aggregator {
+	return func(b bin, column string) float64 {
+		if _, ok := b.ts.Columns[weightColumn]; !ok {
+			return math.NaN()
+		}
+		values, weights := b.values(column), b.values(weightColumn)
+		var sum, totalWeight float64
+		for i, v := range values {
+			if math.IsNaN(v) || math.IsNaN(weights[i]) {
+				continue
+			}
+			sum += v * weights[i]
+			totalWeight += weights[i]
+		}
+		if totalWeight == 0 {
+			return math.NaN()
+		}
+		return sum / totalWeight
+	}
+}
+
+//timeWeightedMean weighs each value by how long it held, until the next row or the end of the bin
+func timeWeightedMean(b bin, column string) float64 {
+	values := b.values(column)
+	var sum, total float64
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		until := b.end
+		if b.head+i+1 < b.tail {
+			until = b.ts.Index[b.head+i+1]
+		}
+		held := float64(until.Sub(b.ts.Index[b.head+i]))
+		sum += v * held
+		total += held
+	}
+	if total <= 0 {
+		return math.NaN()
+	}
+	return sum / total
+}
+
+//mean of arr
+func mean(arr []float64) float64 {
+	sum := 0.0
+	for _, value := range arr {
+		sum += value
+	}
+	return sum / float64(len(arr))
+}
+
+//variance is the sample variance of arr, NaN if less than 2 values
+func variance(arr []float64) float64 {
+	if len(arr) < 2 {
+		return math.NaN()
+	}
+	m := mean(arr)
+	sum := 0.0
+	for _, value := range arr {
+		sum += (value - m) * (value - m)
+	}
+	return sum / float64(len(arr)-1)
+}
+
+//quantile of arr with q in [0, 1], interpolated linearly between values
+func quantile(arr []float64, q float64) float64 {
+	sorted := append([]float64{}, arr...)
+	sort.Float64s(sorted)
+	return quantileOfSorted(sorted, q)
+}
@@ -0,0 +1,831 @@
+package timeseries
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//arrow type ids of the Type union, message header ids and other enums of the flatbuffers schema
+const (
+	arrowNull          = 1
+	arrowInt           = 2
+	arrowFloatingPoint = 3
+	arrowBinary        = 4
+	arrowUtf8          = 5
+	arrowBool          = 6
+	arrowDate          = 8
+	arrowTimestamp     = 10
+	arrowList          = 12
+	arrowStruct        = 13
+	arrowUnion         = 14
+	arrowFixedSizeList = 16
+	arrowMap           = 17
+	arrowLargeBinary   = 19
+	arrowLargeUtf8     = 20
+	arrowLargeList     = 21
+	arrowRunEndEncoded = 22
+	arrowBinaryView    = 23
+
+	arrowSchemaMessage      = 1
+	arrowRecordBatchMessage = 3
+	arrowMetadataV5         = 4
+	arrowDouble             = 2
+	arrowLZ4Frame           = 0
+
+	arrowMagic          = "ARROW1"
+	arrowMaxMessageSize = 1 << 30
+)
+
+//arrowTimeUnits are the units of the arrow TimeUnit enum, in its order
+var arrowTimeUnits = []string{"s", "ms", "us", "ns"}
+
+//ArrowSchema describes the fields of an ArrowRecord
+type ArrowSchema struct {
+	//Names of the Columns
+	Names []string
+	//Unit of the Timestamps, "s", "ms", "us" or "ns"
+	Unit string
+	//Timezone of the Timestamps, an IANA name or an offset like +05:30. empty is read as UTC
+	Timezone string
+	//Metadata is the key-value metadata of the schema
+	Metadata map[string]string
+}
+
+//ArrowRecord is a record batch in the Arrow columnar layout, a timestamp column and float64 columns of the
+//same length with NaN as null. ToArrow shares the memory of the columns with the TimeSeries
+type ArrowRecord struct {
+	Schema     ArrowSchema
+	Timestamps []int64
+	Columns    [][]float64
+}
+
+//ArrowOptions configures ToArrow and the Arrow writers
+type ArrowOptions struct {
+	//BatchSize is the count of rows per record batch, default all rows in one batch
+	BatchSize int
+	//TimeUnit of the timestamp column, "s", "ms", "us" or "ns" (default, what pandas uses)
+	TimeUnit string
+}
+
+//ArrowReadOptions configures the Arrow readers
+type ArrowReadOptions struct {
+	//Columns to load besides the index, default all numeric columns
+	Columns []string
+	//Index is the timestamp column, default the first timestamp or date column
+	Index string
+}
+
+//ToArrow converts the timeseries into record batches. the Index is the column timestamp and Meta the
+//schema metadata. a Local Index is written in UTC, other timezones by name
+func (ts TimeSeries) ToArrow(options ...ArrowOptions) ([]ArrowRecord, error) {
+	var opts ArrowOptions
+	if options != nil {
+		opts = options[0]
+	}
+	if opts.TimeUnit == "" {
+		opts.TimeUnit = "ns"
+	}
+	if !aInB(opts.TimeUnit, arrowTimeUnits) {
+		return nil, fmt.Errorf("arrow write failed: time unit must be s, ms, us or ns not %s", opts.TimeUnit)
+	}
+	columns := ts.ListColumns()
+	sort.Strings(columns)
+	if aInB(parquetIndexColumn, columns) {
+		return nil, fmt.Errorf("arrow write failed: column name %s is reserved for the index", parquetIndexColumn)
+	}
+	schema := ArrowSchema{Names: columns, Unit: opts.TimeUnit, Timezone: "UTC", Metadata: make(map[string]string)}
+	if !ts.IsEmpty() {
+		schema.Timezone = timezoneName(ts.Start())
+	}
+	for k, v := range ts.Meta {
+		schema.Metadata[k] = v
+	}
+	size := opts.BatchSize
+	if size <= 0 {
+		size = ts.Length()
+	}
+	records := make([]ArrowRecord, 0)
+	for start := 0; start == 0 || start < ts.Length(); start += size {
+		end := start + size
+		if end > ts.Length() {
+			end = ts.Length()
+		}
+		record := ArrowRecord{Schema: schema, Timestamps: make([]int64, end-start), Columns: make([][]float64, len(columns))}
+		for i, t := range ts.Index[start:end] {
+			record.Timestamps[i] = arrowFromTime(t, opts.TimeUnit)
+		}
+		for i, col := range columns {
+			record.Columns[i] = ts.Columns[col][start:end]
+		}
+		records = append(records, record)
+		if size == 0 {
+			break
+		}
+	}
+	return records, nil
+}
+
+//NewTimeSeriesFromArrow converts record batches, like the ones ToArrow or the Arrow readers return, into a TimeSeries.
+//the batches must share their schema
+func NewTimeSeriesFromArrow(records []ArrowRecord) (TimeSeries, error) {
+	ts := NewTimeSeries()
+	if len(records) == 0 {
+		return ts, nil
+	}
+	schema := records[0].Schema
+	if !aInB(schema.Unit, arrowTimeUnits) {
+		return ts, fmt.Errorf("arrow read failed: time unit must be s, ms, us or ns not %s", schema.Unit)
+	}
+	location, err := loadTimezone(schema.Timezone)
+	if err != nil {
+		logrus.Warnln("arrow read warning: unknown timezone", schema.Timezone, "using UTC")
+	}
+	for k, v := range schema.Metadata {
+		ts.Meta[k] = v
+	}
+	for _, col := range schema.Names {
+		ts.Columns[col] = make([]float64, 0)
+	}
+	for _, record := range records {
+		if len(record.Columns) != len(schema.Names) || record.Schema.Unit != schema.Unit || len(record.Schema.Names) != len(schema.Names) {
+			return ts, fmt.Errorf("arrow read failed: record batches do not share their schema")
+		}
+		for i, col := range schema.Names {
+			if record.Schema.Names[i] != col || len(record.Columns[i]) != len(record.Timestamps) {
+				return ts, fmt.Errorf("arrow read failed: column %s does not match the schema or has %d rows, want %d", col, len(record.Columns[i]), len(record.Timestamps))
+			}
+			ts.Columns[col] = append(ts.Columns[col], record.Columns[i]...)
+		}
+		for _, v := range record.Timestamps {
+			ts.Index = append(ts.Index, arrowToTime(v, schema.Unit).In(location))
+		}
+	}
+	if !ts.IsEmpty() {
+		ts.changes = append(ts.changes, changelog{"load", ts.End(), ts.Start(), ts.End(), true})
+	}
+	return ts, nil
+}
+
+//WriteAsArrowStream writes the timeseries to path in the Arrow IPC stream format, see ToArrow
+func (ts TimeSeries) WriteAsArrowStream(path string, options ...ArrowOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return ts.WriteArrowStream(f, options...)
+}
+
+//WriteArrowStream writes the timeseries to w in the Arrow IPC stream format, see ToArrow
+func (ts TimeSeries) WriteArrowStream(w io.Writer, options ...ArrowOptions) error {
+	return ts.writeArrow(w, false, options)
+}
+
+//WriteAsFeather writes the timeseries to path as a Feather v2 file, the Arrow IPC file format. see ToArrow
+func (ts TimeSeries) WriteAsFeather(path string, options ...ArrowOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return ts.WriteFeather(f, options...)
+}
+
+//WriteFeather writes the timeseries to w as Feather v2, the Arrow IPC file format. see ToArrow
+func (ts TimeSeries) WriteFeather(w io.Writer, options ...ArrowOptions) error {
+	return ts.writeArrow(w, true, options)
+}
+
+func (ts TimeSeries) writeArrow(w io.Writer, file bool, options []ArrowOptions) error {
+	records, err := ts.ToArrow(options...)
+	if err != nil {
+		return err
+	}
+	return writeArrowIPC(w, records, file)
+}
+
+//NewTimeSeriesFromArrowStream reads a file in the Arrow IPC stream format. timestamp and date columns can be
+//the index, int, float and bool columns are loaded as float64 with null as NaN, other columns are skipped
+func NewTimeSeriesFromArrowStream(path string, options ...ArrowReadOptions) (TimeSeries, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return NewTimeSeries(), err
+	}
+	defer f.Close()
+	return NewTimeSeriesFromArrowStreamReader(f, options...)
+}
+
+//NewTimeSeriesFromArrowStreamReader reads the Arrow IPC stream format from r as NewTimeSeriesFromArrowStream does,
+//one message at a time, so r can be a pipe or a network connection
+func NewTimeSeriesFromArrowStreamReader(r io.Reader, options ...ArrowReadOptions) (TimeSeries, error) {
+	records, err := readArrowStream(r, options)
+	if err != nil {
+		return NewTimeSeries(), err
+	}
+	return NewTimeSeriesFromArrow(records)
+}
+
+//NewTimeSeriesFromFeather reads a Feather v2 file, the Arrow IPC file format, as written by pandas or pyarrow.
+//uncompressed and lz4 compressed files are supported. see NewTimeSeriesFromArrowStream for the columns loaded
+func NewTimeSeriesFromFeather(path string, options ...ArrowReadOptions) (TimeSeries, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return NewTimeSeries(), err
+	}
+	defer f.Close()
+	return NewTimeSeriesFromFeatherReader(f, options...)
+}
+
+//NewTimeSeriesFromFeatherReader reads Feather v2 from r as NewTimeSeriesFromFeather does. the file format is read
+//from its footer, so all of r is read into memory first
+func NewTimeSeriesFromFeatherReader(r io.Reader, options ...ArrowReadOptions) (TimeSeries, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return NewTimeSeries(), err
+	}
+	records, err := readArrowFile(data, options)
+	if err != nil {
+		return NewTimeSeries(), err
+	}
+	return NewTimeSeriesFromArrow(records)
+}
+
+func arrowFromTime(t time.Time, unit string) int64 {
+	switch unit {
+	case "s":
+		return t.Unix()
+	case "ms":
+		return t.UnixMilli()
+	case "us":
+		return t.UnixMicro()
+	}
+	return t.UnixNano()
+}
+
+func arrowToTime(v int64, unit string) time.Time {
+	switch unit {
+	case "s":
+		return time.Unix(v, 0)
+	case "ms":
+		return time.UnixMilli(v)
+	case "us":
+		return time.UnixMicro(v)
+	}
+	return time.Unix(0, v)
+}
+
+//writeArrowIPC writes the schema, the record batches and the end of stream marker. the file format
+//wraps them in magic bytes and appends a footer indexing the batches
+func writeArrowIPC(w io.Writer, records []ArrowRecord, file bool) error {
+	out := &countingWriter{w: w}
+	if file {
+		if _, err := io.WriteString(out, arrowMagic+"\x00\x00"); err != nil {
+			return err
+		}
+	}
+	schema := arrowSchemaTable(records[0].Schema)
+	if _, err := writeArrowMessage(out, arrowSchemaMessage, schema, nil); err != nil {
+		return err
+	}
+	var blocks []byte
+	for _, record := range records {
+		header, body := arrowRecordBatch(record)
+		offset := out.n
+		size, err := writeArrowMessage(out, arrowRecordBatchMessage, header, body)
+		if err != nil {
+			return err
+		}
+		blocks = binary.LittleEndian.AppendUint64(blocks, uint64(offset))
+		blocks = binary.LittleEndian.AppendUint32(blocks, uint32(size))
+		blocks = binary.LittleEndian.AppendUint32(blocks, 0)
+		blocks = binary.LittleEndian.AppendUint64(blocks, uint64(len(body)))
+	}
+	if _, err := out.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}); err != nil {
+		return err
+	}
+	if !file {
+		return nil
+	}
+	footer := new(flatTable).
+		int16(0, arrowMetadataV5).
+		object(1, arrowSchemaTable(records[0].Schema)).
+		object(2, flatStructs{size: 24, align: 8}).
+		object(3, flatStructs{data: blocks, size: 24, align: 8})
+	encoded := encodeFlatBuffer(footer)
+	encoded = binary.LittleEndian.AppendUint32(encoded, uint32(len(encoded)))
+	_, err := out.Write(append(encoded, arrowMagic...))
+	return err
+}
+
+//writeArrowMessage writes an encapsulated message, returning the size of its metadata with the prefix
+func writeArrowMessage(out io.Writer, headerType uint8, header *flatTable, body []byte) (int, error) {
+	message := new(flatTable).
+		int16(0, arrowMetadataV5).
+		uint8(1, headerType).
+		object(2, header).
+		int64(3, int64(len(body)))
+	encoded := encodeFlatBuffer(message)
+	prefix := binary.LittleEndian.AppendUint32([]byte{0xff, 0xff, 0xff, 0xff}, uint32(len(encoded)))
+	if _, err := out.Write(append(prefix, encoded...)); err != nil {
+		return 0, err
+	}
+	_, err := out.Write(body)
+	return len(prefix) + len(encoded), err
+}
+
+//arrowSchemaTable encodes the schema, the timestamp column first
+func arrowSchemaTable(schema ArrowSchema) *flatTable {
+	unit := int16(0)
+	for i, u := range arrowTimeUnits {
+		if u == schema.Unit {
+			unit = int16(i)
+		}
+	}
+	fields := flatVector{new(flatTable).
+		object(0, flatString(parquetIndexColumn)).
+		boolean(1, false).
+		uint8(2, arrowTimestamp).
+		object(3, new(flatTable).int16(0, unit).object(1, flatString(schema.Timezone))).
+		object(5, flatVector{})}
+	for _, name := range schema.Names {
+		fields = append(fields, new(flatTable).
+			object(0, flatString(name)).
+			boolean(1, true).
+			uint8(2, arrowFloatingPoint).
+			object(3, new(flatTable).int16(0, arrowDouble)).
+			object(5, flatVector{}))
+	}
+	table := new(flatTable).int16(0, 0).object(1, fields)
+	if len(schema.Metadata) > 0 {
+		keys := make([]string, 0, len(schema.Metadata))
+		for k := range schema.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		metadata := make(flatVector, len(keys))
+		for i, k := range keys {
+			metadata[i] = new(flatTable).object(0, flatString(k)).object(1, flatString(schema.Metadata[k]))
+		}
+		table.object(2, metadata)
+	}
+	return table
+}
+
+//arrowRecordBatch encodes the header and the body of a record batch, NaN are written as null
+func arrowRecordBatch(record ArrowRecord) (*flatTable, []byte) {
+	rows := len(record.Timestamps)
+	var body, nodes, buffers []byte
+	buffer := func(data []byte) {
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(body)))
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(data)))
+		body = append(body, data...)
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+	node := func(nulls int) {
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(rows))
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(nulls))
+	}
+	values := make([]byte, 0, 8*rows)
+	for _, v := range record.Timestamps {
+		values = binary.LittleEndian.AppendUint64(values, uint64(v))
+	}
+	node(0)
+	buffer(nil)
+	buffer(values)
+	for _, column := range record.Columns {
+		validity := make([]byte, (rows+7)/8)
+		values := make([]byte, 0, 8*rows)
+		nulls := 0
+		for i, v := range column {
+			if math.IsNaN(v) {
+				nulls++
+			} else {
+				validity[i/8] |= 1 << (i % 8)
+			}
+			values = binary.LittleEndian.AppendUint64(values, math.Float64bits(v))
+		}
+		node(nulls)
+		if nulls == 0 {
+			validity = nil
+		}
+		buffer(validity)
+		buffer(values)
+	}
+	header := new(flatTable).
+		int64(0, int64(rows)).
+		object(1, flatStructs{data: nodes, size: 16, align: 8}).
+		object(2, flatStructs{data: buffers, size: 16, align: 8})
+	return header, body
+}
+
+//arrowField is a field of a decoded schema
+type arrowField struct {
+	name       string
+	typ        uint8
+	typeTable  flatView
+	dictionary bool
+	children   []arrowField
+}
+
+func newArrowField(v flatView) arrowField {
+	f := arrowField{name: v.str(0), typ: v.uint8(2, 0)}
+	f.typeTable, _ = v.table(3)
+	_, f.dictionary = v.table(4)
+	for _, child := range v.tables(5) {
+		f.children = append(f.children, newArrowField(child))
+	}
+	return f
+}
+
+//layout counts the field nodes and buffers the field takes in a record batch
+func (f arrowField) layout() (int, int, error) {
+	if f.dictionary {
+		return 1, 2, nil
+	}
+	nodes, buffers := 1, 2
+	switch f.typ {
+	case arrowNull, arrowRunEndEncoded:
+		buffers = 0
+	case arrowBinary, arrowUtf8, arrowLargeBinary, arrowLargeUtf8:
+		buffers = 3
+	case arrowStruct, arrowFixedSizeList:
+		buffers = 1
+	case arrowList, arrowLargeList, arrowMap:
+		buffers = 2
+	case arrowUnion:
+		buffers = 1 + int(f.typeTable.int16(0, 0))
+	}
+	if f.typ >= arrowBinaryView {
+		return 0, 0, fmt.Errorf("arrow read failed: column %s has the unsupported type %d", f.name, f.typ)
+	}
+	for _, child := range f.children {
+		n, b, err := child.layout()
+		if err != nil {
+			return 0, 0, err
+		}
+		nodes, buffers = nodes+n, buffers+b
+	}
+	return nodes, buffers, nil
+}
+
+//timeUnit of a timestamp or date field, empty if the field is neither. dates are read in ms
+func (f arrowField) timeUnit() string {
+	switch {
+	case f.dictionary:
+	case f.typ == arrowTimestamp:
+		return arrowTimeUnits[f.typeTable.int16(0, 0)%4]
+	case f.typ == arrowDate:
+		return "ms"
+	}
+	return ""
+}
+
+//numeric tells if the field can be loaded as float64
+func (f arrowField) numeric() bool {
+	if f.dictionary {
+		return false
+	}
+	switch f.typ {
+	case arrowInt, arrowBool:
+		return true
+	case arrowFloatingPoint:
+		return f.typeTable.int16(0, 0) > 0
+	}
+	return false
+}
+
+//arrowReader decodes record batches of the fields selected from a schema
+type arrowReader struct {
+	fields   []arrowField
+	index    int
+	selected map[int]bool
+	schema   ArrowSchema
+	records  []ArrowRecord
+}
+
+func newArrowReader(schema flatView, options []ArrowReadOptions) (*arrowReader, error) {
+	var opts ArrowReadOptions
+	if options != nil {
+		opts = options[0]
+	}
+	r := &arrowReader{index: -1, selected: make(map[int]bool), schema: ArrowSchema{Metadata: make(map[string]string)}}
+	if schema.int16(0, 0) != 0 {
+		return nil, fmt.Errorf("arrow read failed: big endian data is not supported")
+	}
+	for _, v := range schema.tables(1) {
+		r.fields = append(r.fields, newArrowField(v))
+	}
+	for _, kv := range schema.tables(2) {
+		r.schema.Metadata[kv.str(0)] = kv.str(1)
+	}
+	for i, f := range r.fields {
+		if (opts.Index == "" && f.timeUnit() != "") || (opts.Index != "" && f.name == opts.Index) {
+			r.index = i
+			break
+		}
+	}
+	if r.index < 0 || r.fields[r.index].timeUnit() == "" {
+		return nil, fmt.Errorf("arrow read failed: no timestamp column %s found for the index", opts.Index)
+	}
+	index := r.fields[r.index]
+	r.schema.Unit = index.timeUnit()
+	if index.typ == arrowTimestamp {
+		r.schema.Timezone = index.typeTable.str(1)
+	}
+	for _, col := range opts.Columns {
+		found := false
+		for _, f := range r.fields {
+			found = found || f.name == col
+		}
+		if !found {
+			return nil, fmt.Errorf("arrow read failed: no such column %s", col)
+		}
+	}
+	for i, f := range r.fields {
+		if i == r.index {
+			continue
+		}
+		if (opts.Columns == nil && f.numeric()) || aInB(f.name, opts.Columns) {
+			if !f.numeric() {
+				return nil, fmt.Errorf("arrow read failed: column %s is not numeric", f.name)
+			}
+			r.selected[i] = true
+			r.schema.Names = append(r.schema.Names, f.name)
+		}
+	}
+	return r, nil
+}
+
+//recordBatch decodes a record batch message with its body
+func (r *arrowReader) recordBatch(header flatView, body []byte) error {
+	rows := int(header.int64(0, 0))
+	nodes, _ := header.vector(1)
+	buffers, bufferCount := header.vector(2)
+	codec := -1
+	if compression, ok := header.table(3); ok {
+		codec = int(compression.uint8(0, 0))
+	}
+	buffer := func(i int) ([]byte, error) {
+		if i >= bufferCount {
+			return nil, fmt.Errorf("arrow read failed: record batch has %d buffers", bufferCount)
+		}
+		offset := int64(binary.LittleEndian.Uint64(header.buf[buffers+16*i:]))
+		length := int64(binary.LittleEndian.Uint64(header.buf[buffers+16*i+8:]))
+		if offset < 0 || length < 0 || offset+length > int64(len(body)) {
+			return nil, fmt.Errorf("arrow read failed: buffer %d past the end of the body", i)
+		}
+		data := body[offset : offset+length]
+		if codec < 0 || len(data) == 0 {
+			return data, nil
+		}
+		if len(data) < 8 {
+			return nil, fmt.Errorf("arrow read failed: truncated compressed buffer")
+		}
+		if int64(binary.LittleEndian.Uint64(data)) == -1 {
+			return data[8:], nil
+		}
+		if codec != arrowLZ4Frame {
+			return nil, fmt.Errorf("arrow read failed: only lz4 compression is supported")
+		}
+		return lz4FrameDecode(data[8:])
+	}
+	record := ArrowRecord{Schema: r.schema, Columns: make([][]float64, 0, len(r.selected))}
+	node, next := 0, 0
+	for i, f := range r.fields {
+		n, b, err := f.layout()
+		if err != nil {
+			return err
+		}
+		if i == r.index || r.selected[i] {
+			nulls := binary.LittleEndian.Uint64(header.buf[nodes+16*node+8:])
+			validity, err := buffer(next)
+			if err != nil {
+				return err
+			}
+			values, err := buffer(next + 1)
+			if err != nil {
+				return err
+			}
+			if nulls == 0 {
+				validity = nil
+			}
+			if len(validity) > 0 && len(validity) < (rows+7)/8 {
+				return fmt.Errorf("arrow read failed: truncated validity of column %s", f.name)
+			}
+			if i == r.index {
+				if validity != nil {
+					return fmt.Errorf("arrow read failed: null timestamp in index column %s", f.name)
+				}
+				if record.Timestamps, err = f.timestamps(values, rows); err != nil {
+					return err
+				}
+			} else {
+				column, err := f.floats(values, validity, rows)
+				if err != nil {
+					return err
+				}
+				record.Columns = append(record.Columns, column)
+			}
+		}
+		node, next = node+n, next+b
+	}
+	r.records = append(r.records, record)
+	return nil
+}
+
+//timestamps decodes the index field into the unit of timeUnit
+func (f arrowField) timestamps(values []byte, rows int) ([]int64, error) {
+	size := 8
+	if f.typ == arrowDate && f.typeTable.int16(0, 1) == 0 {
+		size = 4
+	}
+	if len(values) < size*rows {
+		return nil, fmt.Errorf("arrow read failed: truncated values of column %s", f.name)
+	}
+	out := make([]int64, rows)
+	for i := range out {
+		if size == 4 { //days
+			out[i] = int64(int32(binary.LittleEndian.Uint32(values[4*i:]))) * 86400000
+		} else {
+			out[i] = int64(binary.LittleEndian.Uint64(values[8*i:]))
+		}
+	}
+	return out, nil
+}
+
+//floats decodes a numeric field, nulls are NaN
+func (f arrowField) floats(values, validity []byte, rows int) ([]float64, error) {
+	out := make([]float64, rows)
+	bits := 1
+	switch f.typ {
+	case arrowInt:
+		bits = int(f.typeTable.int32(0, 0))
+	case arrowFloatingPoint:
+		bits = 32
+		if f.typeTable.int16(0, 0) == arrowDouble {
+			bits = 64
+		}
+	}
+	if bits != 1 && bits != 8 && bits != 16 && bits != 32 && bits != 64 {
+		return nil, fmt.Errorf("arrow read failed: invalid bit width %d of column %s", bits, f.name)
+	}
+	if len(values)*8 < bits*rows {
+		return nil, fmt.Errorf("arrow read failed: truncated values of column %s", f.name)
+	}
+	signed := f.typeTable.boolean(1)
+	for i := range out {
+		if validity != nil && validity[i/8]>>(i%8)&1 == 0 {
+			out[i] = math.NaN()
+			continue
+		}
+		switch {
+		case f.typ == arrowBool:
+			out[i] = float64(values[i/8] >> (i % 8) & 1)
+		case f.typ == arrowFloatingPoint && bits == 32:
+			out[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(values[4*i:])))
+		case f.typ == arrowFloatingPoint:
+			out[i] = math.Float64frombits(binary.LittleEndian.Uint64(values[8*i:]))
+		case bits == 8 && signed:
+			out[i] = float64(int8(values[i]))
+		case bits == 8:
+			out[i] = float64(values[i])
+		case bits == 16 && signed:
+			out[i] = float64(int16(binary.LittleEndian.Uint16(values[2*i:])))
+		case bits == 16:
+			out[i] = float64(binary.LittleEndian.Uint16(values[2*i:]))
+		case bits == 32 && signed:
+			out[i] = float64(int32(binary.LittleEndian.Uint32(values[4*i:])))
+		case bits == 32:
+			out[i] = float64(binary.LittleEndian.Uint32(values[4*i:]))
+		case signed:
+			out[i] = float64(int64(binary.LittleEndian.Uint64(values[8*i:])))
+		default:
+			out[i] = float64(binary.LittleEndian.Uint64(values[8*i:]))
+		}
+	}
+	return out, nil
+}
+
+//readArrowMessage reads an encapsulated message, io.EOF at the end of the stream
+func readArrowMessage(r io.Reader) (flatView, []byte, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return flatView{}, nil, err
+	}
+	size := int32(binary.LittleEndian.Uint32(prefix[:]))
+	if size == -1 { //continuation marker, the length follows
+		if _, err := io.ReadFull(r, prefix[:]); err != nil {
+			return flatView{}, nil, err
+		}
+		size = int32(binary.LittleEndian.Uint32(prefix[:]))
+	}
+	if size == 0 {
+		return flatView{}, nil, io.EOF
+	}
+	if size < 0 || size > arrowMaxMessageSize {
+		return flatView{}, nil, fmt.Errorf("arrow read failed: invalid message size %d", size)
+	}
+	metadata := make([]byte, size)
+	if _, err := io.ReadFull(r, metadata); err != nil {
+		return flatView{}, nil, err
+	}
+	message := flatRoot(metadata)
+	length := message.int64(3, 0)
+	if length < 0 || length > arrowMaxMessageSize*4 {
+		return flatView{}, nil, fmt.Errorf("arrow read failed: invalid body size %d", length)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return flatView{}, nil, err
+	}
+	return message, body, nil
+}
+
+//readArrowStream reads the record batches of a stream, dictionary batches are skipped
+func readArrowStream(in io.Reader, options []ArrowReadOptions) (records []ArrowRecord, err error) {
+	defer func() {
+		if recover() != nil {
+			records, err = nil, fmt.Errorf("arrow read failed: malformed metadata")
+		}
+	}()
+	message, _, err := readArrowMessage(in)
+	if err != nil {
+		return nil, fmt.Errorf("arrow read failed: no schema: %v", err)
+	}
+	schema, _ := message.table(2)
+	if message.uint8(1, 0) != arrowSchemaMessage {
+		return nil, fmt.Errorf("arrow read failed: the stream does not start with a schema")
+	}
+	r, err := newArrowReader(schema, options)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		message, body, err := readArrowMessage(in)
+		if err == io.EOF {
+			return r.records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if message.uint8(1, 0) != arrowRecordBatchMessage {
+			continue
+		}
+		header, _ := message.table(2)
+		if err := r.recordBatch(header, body); err != nil {
+			return nil, err
+		}
+	}
+}
+
+//readArrowFile reads the record batches the footer of an Arrow IPC file indexes
+func readArrowFile(data []byte, options []ArrowReadOptions) (records []ArrowRecord, err error) {
+	defer func() {
+		if recover() != nil {
+			records, err = nil, fmt.Errorf("arrow read failed: malformed metadata")
+		}
+	}()
+	if bytes.HasPrefix(data, []byte("FEA1")) {
+		return nil, fmt.Errorf("arrow read failed: feather v1 files are not supported")
+	}
+	if len(data) < 18 || string(data[:6]) != arrowMagic || string(data[len(data)-6:]) != arrowMagic {
+		return nil, fmt.Errorf("arrow read failed: not an arrow file")
+	}
+	size := int(int32(binary.LittleEndian.Uint32(data[len(data)-10:])))
+	if size <= 0 || size > len(data)-18 {
+		return nil, fmt.Errorf("arrow read failed: invalid footer size %d", size)
+	}
+	footer := flatRoot(data[len(data)-10-size : len(data)-10])
+	schema, _ := footer.table(1)
+	r, err := newArrowReader(schema, options)
+	if err != nil {
+		return nil, err
+	}
+	start, n := footer.vector(3)
+	for i := 0; i < n; i++ {
+		offset := int64(binary.LittleEndian.Uint64(footer.buf[start+24*i:]))
+		if offset < 0 || offset >= int64(len(data)) {
+			return nil, fmt.Errorf("arrow read failed: record batch past the end of the file")
+		}
+		message, body, err := readArrowMessage(bytes.NewReader(data[offset:]))
+		if err != nil {
+			return nil, fmt.Errorf("arrow read failed: record batch %d: %v", i, err)
+		}
+		header, _ := message.table(2)
+		if err := r.recordBatch(header, body); err != nil {
+			return nil, err
+		}
+	}
+	return r.records, nil
+}
@@ -0,0 +1,347 @@
+package timeseries
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+//BarOptions names the tick columns bars are built from, default price and size.
+//Span is the span of the moving averages imbalance bars keep of bar length and tick imbalance, default 20
+type BarOptions struct {
+	Price string
+	Size  string
+	Span  int
+}
+
+//BarBuilder aggregates trade ticks into bars with columns open, high, low, close and volume.
+//feed it ticks with AppendDataPoint as they arrive, every completed bar is appended to Bars.
+//a tick never spans two bars, so volume and dollar bars overshoot their threshold by the last tick.
+//time bars are timestamped at the start of their bin like Resample, every other bar at the tick completing it
+type BarBuilder struct {
+	Bars TimeSeries
+
+	kind        string
+	threshold   float64
+	price, size string
+
+	//current bar
+	open, high, low, close, volume, value float64
+	ticks                                 int
+	last                                  time.Time
+
+	//time bars
+	interval         string
+	binStart, binEnd func(time.Time) time.Time
+	start, end       time.Time
+
+	//imbalance bars
+	alpha, sign, lastPrice       float64
+	imbalance, expectedImbalance float64
+	expectedTicks                float64
+	priced, seeded               bool
+}
+
+//NewTimeBarBuilder builds bars over a fixed or calendar interval, fixed intervals are aligned to midnight
+//of the first tick. a bar completes when the first tick of a later bin arrives, use Flush to close it earlier
+func NewTimeBarBuilder(interval string, options ...BarOptions) (*BarBuilder, error) {
+	if _, _, _, err := parseBins(interval, time.Time{}); err != nil {
+		return nil, err
+	}
+	b := newBarBuilder("time", 0, options)
+	b.interval = interval
+	return b, nil
+}
+
+//NewTickBarBuilder builds a bar every n ticks
+func NewTickBarBuilder(n int, options ...BarOptions) *BarBuilder {
+	return newBarBuilder("tick", float64(n), options)
+}
+
+//NewVolumeBarBuilder builds a bar every time the traded size reaches threshold
+func NewVolumeBarBuilder(threshold float64, options ...BarOptions) *BarBuilder {
+	return newBarBuilder("volume", threshold, options)
+}
+
+//NewDollarBarBuilder builds a bar every time the traded value (price*size) reaches threshold
+func NewDollarBarBuilder(threshold float64, options ...BarOptions) *BarBuilder {
+	return newBarBuilder("dollar", threshold, options)
+}
+
+//NewRangeBarBuilder builds a bar every time the high-low range of the bar reaches size
+func NewRangeBarBuilder(size float64, options ...BarOptions) *BarBuilder {
+	return newBarBuilder("range", size, options)
+}
+
+//NewImbalanceBarBuilder builds a bar every time the signed flow of the bar exceeds what is expected of a bar.
+//by is the flow counted, "tick" (±1), "volume" (±size) or "dollar" (±price*size), signed by the tick rule.
+//a bar completes when |flow| >= expected ticks per bar * |expected flow per tick|, both expectations are
+//exponential moving averages over Span bars and ticks. expectedTicks seeds the ticks per bar and the first price
+//change the flow per tick, ticks before it have no sign and add no flow.
+//when the flow is balanced the expected flow per tick nears 0 and bars shorten, pick Span accordingly
+func NewImbalanceBarBuilder(by string, expectedTicks float64, options ...BarOptions) (*BarBuilder, error) {
+	if by != "tick" && by != "volume" && by != "dollar" {
+		return nil, fmt.Errorf("imbalance bars failed: by must be tick, volume or dollar not %s", by)
+	}
+	if expectedTicks < 1 {
+		return nil, fmt.Errorf("imbalance bars failed: expected ticks must be at least 1 not %v", expectedTicks)
+	}
+	b := newBarBuilder(by+"_imbalance", 0, options)
+	b.expectedTicks = expectedTicks
+	return b, nil
+}
+
+func newBarBuilder(kind string, threshold float64, options []BarOptions) *BarBuilder {
+	opts := BarOptions{Price: "price", Size: "size", Span: 20}
+	if options != nil {
+		if options[0].Price != "" {
+			opts.Price = options[0].Price
+		}
+		if options[0].Size != "" {
+			opts.Size = options[0].Size
+		}
+		if options[0].Span > 0 {
+			opts.Span = options[0].Span
+		}
+	}
+	bars := NewTimeSeries()
+	for _, col := range []string{"open", "high", "low", "close", "volume"} {
+		bars.Columns[col] = make([]float64, 0)
+	}
+	return &BarBuilder{
+		Bars:      bars,
+		kind:      kind,
+		threshold: threshold,
+		price:     opts.Price,
+		size:      opts.Size,
+		alpha:     2 / float64(opts.Span+1),
+	}
+}
+
+//AppendDataPoint adds the next tick, returns true if it completed a bar.
+//ticks without a price are skipped, a missing size counts as 0
+func (b *BarBuilder) AppendDataPoint(dp DataPoint) (bool, error) {
+	price, ok := dp.Columns[b.price]
+	if !ok {
+		return false, fmt.Errorf("failed to append tick: field missing %s", b.price)
+	}
+	return b.add(dp.Index, price, dp.Columns[b.size])
+}
+
+//Partial returns the bar being built from the ticks after the last completed bar, false if there are none
+func (b *BarBuilder) Partial() (DataPoint, bool) {
+	if b.ticks == 0 {
+		return NewDataPoint(), false
+	}
+	return b.datapoint(), true
+}
+
+//Flush completes the partial bar, for ex:- at the end of a session. returns true if there was one
+func (b *BarBuilder) Flush() bool {
+	if b.ticks == 0 {
+		return false
+	}
+	b.complete()
+	return true
+}
+
+//add a tick to the current bar
+func (b *BarBuilder) add(t time.Time, price, size float64) (bool, error) {
+	if t.Before(b.last) {
+		return false, fmt.Errorf("failed to append tick: %v is before the last tick %v", t, b.last)
+	}
+	b.last = t
+	if math.IsNaN(price) {
+		return false, nil
+	}
+	if math.IsNaN(size) {
+		size = 0
+	}
+	completed := false
+	if b.kind == "time" {
+		if b.binStart == nil {
+			y, m, d := t.Date()
+			b.binStart, b.binEnd, _, _ = parseBins(b.interval, time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+		}
+		if b.ticks > 0 && !t.Before(b.end) {
+			b.complete()
+			completed = true
+		}
+		if b.ticks == 0 {
+			b.start = b.binStart(t)
+			b.end = b.binEnd(b.start)
+		}
+	}
+	if b.ticks == 0 {
+		b.open, b.high, b.low = price, price, price
+	}
+	b.high = math.Max(b.high, price)
+	b.low = math.Min(b.low, price)
+	b.close = price
+	b.volume += size
+	b.value += price * size
+	b.ticks++
+	if b.kind == "time" {
+		return completed, nil
+	}
+	if b.full(price, size) {
+		b.complete()
+		return true, nil
+	}
+	return false, nil
+}
+
+//full checks if the current bar is complete after adding a tick
+func (b *BarBuilder) full(price, size float64) bool {
+	switch b.kind {
+	case "tick":
+		return float64(b.ticks) >= b.threshold
+	case "volume":
+		return b.volume >= b.threshold
+	case "dollar":
+		return b.value >= b.threshold
+	case "range":
+		return b.high-b.low >= b.threshold
+	}
+	//tick rule, the sign of the last price change
+	if b.priced && price != b.lastPrice {
+		b.sign = math.Copysign(1, price-b.lastPrice)
+	}
+	b.lastPrice, b.priced = price, true
+	if b.sign == 0 { //no price change yet
+		return false
+	}
+	flow := b.sign
+	switch b.kind {
+	case "volume_imbalance":
+		flow *= size
+	case "dollar_imbalance":
+		flow *= price * size
+	}
+	b.imbalance += flow
+	if !b.seeded {
+		b.expectedImbalance, b.seeded = flow, true
+	} else {
+		b.expectedImbalance += b.alpha * (flow - b.expectedImbalance)
+	}
+	if b.imbalance == 0 || math.Abs(b.imbalance) < b.expectedTicks*math.Abs(b.expectedImbalance) {
+		return false
+	}
+	b.expectedTicks += b.alpha * (float64(b.ticks) - b.expectedTicks)
+	return true
+}
+
+//complete appends the current bar to Bars and starts a new one
+func (b *BarBuilder) complete() {
+	dp := b.datapoint()
+	b.Bars.Index = append(b.Bars.Index, dp.Index)
+	for k, v := range dp.Columns {
+		b.Bars.Columns[k] = append(b.Bars.Columns[k], v)
+	}
+	if b.Bars.MaxSize != 0 {
+		b.Bars = b.Bars.SetMaxSize(b.Bars.MaxSize)
+	}
+	b.volume, b.value, b.imbalance, b.ticks = 0, 0, 0, 0
+}
+
+//datapoint of the current bar
+func (b *BarBuilder) datapoint() DataPoint {
+	dp := NewDataPoint()
+	dp.Index = b.last
+	if b.kind == "time" {
+		dp.Index = b.start
+	}
+	dp.Columns["open"] = b.open
+	dp.Columns["high"] = b.high
+	dp.Columns["low"] = b.low
+	dp.Columns["close"] = b.close
+	dp.Columns["volume"] = b.volume
+	return dp
+}
+
+//TimeBars builds bars over a fixed or calendar interval from a tick `TimeSeries`, the last bar included
+func (ts TimeSeries) TimeBars(interval string, options ...BarOptions) (TimeSeries, error) {
+	b, err := NewTimeBarBuilder(interval, options...)
+	if err != nil {
+		return ts, err
+	}
+	bars, err := b.result(ts)
+	if err == nil {
+		b.Flush()
+		bars = b.Bars
+	}
+	return bars, err
+}
+
+//TickBars builds a bar every n ticks. trailing ticks which dont complete a bar are left out
+func (ts TimeSeries) TickBars(n int, options ...BarOptions) (TimeSeries, error) {
+	if n < 1 {
+		return ts, fmt.Errorf("tick bars failed: n must be at least 1 not %d", n)
+	}
+	b := NewTickBarBuilder(n, options...)
+	return b.result(ts)
+}
+
+//VolumeBars builds a bar every time the traded size reaches threshold. trailing ticks which dont complete a bar are left out
+func (ts TimeSeries) VolumeBars(threshold float64, options ...BarOptions) (TimeSeries, error) {
+	if threshold <= 0 {
+		return ts, fmt.Errorf("volume bars failed: threshold must be positive not %v", threshold)
+	}
+	b := NewVolumeBarBuilder(threshold, options...)
+	return b.result(ts)
+}
+
+//DollarBars builds a bar every time the traded value reaches threshold. trailing ticks which dont complete a bar are left out
+func (ts TimeSeries) DollarBars(threshold float64, options ...BarOptions) (TimeSeries, error) {
+	if threshold <= 0 {
+		return ts, fmt.Errorf("dollar bars failed: threshold must be positive not %v", threshold)
+	}
+	b := NewDollarBarBuilder(threshold, options...)
+	return b.result(ts)
+}
+
+//RangeBars builds a bar every time the price range reaches size. trailing ticks which dont complete a bar are left out
+func (ts TimeSeries) RangeBars(size float64, options ...BarOptions) (TimeSeries, error) {
+	if size <= 0 {
+		return ts, fmt.Errorf("range bars failed: size must be positive not %v", size)
+	}
+	b := NewRangeBarBuilder(size, options...)
+	return b.result(ts)
+}
+
+//ImbalanceBars builds tick, volume or dollar imbalance bars, see NewImbalanceBarBuilder.
+//trailing ticks which dont complete a bar are left out
+func (ts TimeSeries) ImbalanceBars(by string, expectedTicks float64, options ...BarOptions) (TimeSeries, error) {
+	b, err := NewImbalanceBarBuilder(by, expectedTicks, options...)
+	if err != nil {
+		return ts, err
+	}
+	return b.result(ts)
+}
+
+//result builds the bars of ts, ts is returned on error
+func (b *BarBuilder) result(ts TimeSeries) (TimeSeries, error) {
+	if err := b.build(ts); err != nil {
+		return ts, err
+	}
+	return b.Bars, nil
+}
+
+//build feeds every row of ts to the builder
+func (b *BarBuilder) build(ts TimeSeries) error {
+	prices, ok := ts.Columns[b.price]
+	if !ok {
+		return fmt.Errorf("%s bars failed: no such column %s", b.kind, b.price)
+	}
+	sizes, ok := ts.Columns[b.size]
+	for i, t := range ts.Index {
+		size := math.NaN()
+		if ok {
+			size = sizes[i]
+		}
+		if _, err := b.add(t, prices[i], size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
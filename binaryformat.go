@@ -0,0 +1,472 @@
+package timeseries
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//the binary format is the header, then blocks, then the block index:
+//	"TSGB" version:u8 size:u32 header: columns, Meta and the timezone, an IANA name or an offset like +05:30, as uvarint prefixed strings
+//	per block: size:u32 payload crc32(payload):u32, the payload holding rows:uvarint first:varint last:varint,
+//	then the gorilla encoded timestamps and each column in header order, uvarint size prefixed
+//	per block: offset:u64 size:u64 rows:u64 first:i64 last:i64, then count:u32 "TSIX"
+//timestamps are unix nanoseconds. a missing or torn index is rebuilt by scanning the blocks
+const (
+	binaryMagic      = "TSGB"
+	binaryIndexMagic = "TSIX"
+	binaryVersion    = 1
+	binaryBlockSize  = 8192
+	binaryEntrySize  = 40
+	binaryMaxSize    = 1 << 30
+)
+
+//BinaryOptions configures WriteAsBinary and AppendToBinary
+type BinaryOptions struct {
+	//BlockSize is the count of rows per block, default 8192. smaller blocks make reads of a time range finer
+	BlockSize int
+}
+
+//BinaryReadOptions configures NewTimeSeriesFromBinary
+type BinaryReadOptions struct {
+	//Columns to load, default all
+	Columns []string
+	//Start and End bound the rows loaded, both inclusive, zero values are unbounded.
+	//blocks outside are not read at all
+	Start time.Time
+	End   time.Time
+}
+
+//binaryBlock is an entry of the block index
+type binaryBlock struct {
+	offset int64
+	size   int64 //of the payload
+	rows   int64
+	first  int64
+	last   int64
+}
+
+//binaryFile is the header and the block index of a file
+type binaryFile struct {
+	columns  []string
+	meta     map[string]string
+	timezone string
+	blocks   []binaryBlock
+	dataEnd  int64 //where the index starts, appends truncate here
+}
+
+//binaryCursor reads the varints and strings of a header or a payload, the first error sticks
+type binaryCursor struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (c *binaryCursor) uvarint() uint64 {
+	if c.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(c.buf[c.pos:])
+	if n <= 0 {
+		c.err = fmt.Errorf("binary read failed: invalid varint at %d", c.pos)
+		return 0
+	}
+	c.pos += n
+	return v
+}
+
+func (c *binaryCursor) varint() int64 {
+	if c.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(c.buf[c.pos:])
+	if n <= 0 {
+		c.err = fmt.Errorf("binary read failed: invalid varint at %d", c.pos)
+		return 0
+	}
+	c.pos += n
+	return v
+}
+
+func (c *binaryCursor) bytes() []byte {
+	n := c.uvarint()
+	if c.err != nil {
+		return nil
+	}
+	if n > uint64(len(c.buf)-c.pos) {
+		c.err = fmt.Errorf("binary read failed: %d bytes past the end of data", n)
+		return nil
+	}
+	c.pos += int(n)
+	return c.buf[c.pos-int(n) : c.pos]
+}
+
+func (c *binaryCursor) str() string {
+	return string(c.bytes())
+}
+
+func appendBinaryString(buf []byte, s string) []byte {
+	return append(binary.AppendUvarint(buf, uint64(len(s))), s...)
+}
+
+//WriteAsBinary writes the timeseries to path in the native binary format: gorilla compressed blocks of rows,
+//delta of delta timestamps and XOR compressed columns, lossless unlike WriteAsCSV. the header keeps the columns,
+//Meta and the timezone, and an index of the time range of each block lets NewTimeSeriesFromBinary read only
+//the blocks it needs. see AppendToBinary to add rows later
+func (ts TimeSeries) WriteAsBinary(path string, options ...BinaryOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return ts.WriteBinary(f, options...)
+}
+
+//WriteBinary writes the timeseries to w in the binary format as WriteAsBinary does
+func (ts TimeSeries) WriteBinary(w io.Writer, options ...BinaryOptions) error {
+	columns := ts.ListColumns()
+	sort.Strings(columns)
+	keys := make([]string, 0, len(ts.Meta))
+	for k := range ts.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	header := binary.AppendUvarint(nil, uint64(len(columns)))
+	for _, col := range columns {
+		header = appendBinaryString(header, col)
+	}
+	header = binary.AppendUvarint(header, uint64(len(keys)))
+	for _, k := range keys {
+		header = appendBinaryString(appendBinaryString(header, k), ts.Meta[k])
+	}
+	timezone := time.UTC.String()
+	if !ts.IsEmpty() {
+		timezone = timezoneName(ts.Start())
+	}
+	header = appendBinaryString(header, timezone)
+	prefix := append([]byte(binaryMagic), binaryVersion)
+	prefix = binary.LittleEndian.AppendUint32(prefix, uint32(len(header)))
+	out := &countingWriter{w: w}
+	if _, err := out.Write(append(prefix, header...)); err != nil {
+		return err
+	}
+	blocks, err := ts.writeBinaryBlocks(out, columns, options)
+	if err != nil {
+		return err
+	}
+	return writeBinaryIndex(out, blocks)
+}
+
+//AppendToBinary appends the timeseries as new blocks to a file WriteAsBinary wrote, or writes it if there is none.
+//the timeseries must have the columns of the file and start after its last timestamp, the header and its Meta are kept
+func (ts TimeSeries) AppendToBinary(path string, options ...BinaryOptions) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return ts.WriteAsBinary(path, options...)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	file, err := openBinary(f, stat.Size())
+	if err != nil {
+		return err
+	}
+	columns := ts.ListColumns()
+	sort.Strings(columns)
+	mismatch := len(columns) != len(file.columns)
+	for i := 0; !mismatch && i < len(columns); i++ {
+		mismatch = columns[i] != file.columns[i]
+	}
+	if mismatch {
+		return fmt.Errorf("binary append failed: columns %v do not match the file's %v", columns, file.columns)
+	}
+	if n := len(file.blocks); n > 0 && !ts.IsEmpty() && ts.Start().UnixNano() <= file.blocks[n-1].last {
+		return fmt.Errorf("binary append failed: %v is not after the last timestamp of the file", ts.Start())
+	}
+	if err := f.Truncate(file.dataEnd); err != nil {
+		return err
+	}
+	if _, err := f.Seek(file.dataEnd, io.SeekStart); err != nil {
+		return err
+	}
+	out := &countingWriter{w: f, n: file.dataEnd}
+	blocks, err := ts.writeBinaryBlocks(out, columns, options)
+	if err != nil {
+		return err
+	}
+	return writeBinaryIndex(out, append(file.blocks, blocks...))
+}
+
+//writeBinaryBlocks writes the rows in blocks, returning their index entries
+func (ts TimeSeries) writeBinaryBlocks(out *countingWriter, columns []string, options []BinaryOptions) ([]binaryBlock, error) {
+	size := binaryBlockSize
+	if options != nil && options[0].BlockSize > 0 {
+		size = options[0].BlockSize
+	}
+	blocks := make([]binaryBlock, 0)
+	for start := 0; start < ts.Length(); start += size {
+		end := start + size
+		if end > ts.Length() {
+			end = ts.Length()
+		}
+		timestamps := make([]int64, end-start)
+		for i, t := range ts.Index[start:end] {
+			timestamps[i] = t.UnixNano()
+		}
+		block := binaryBlock{offset: out.n, rows: int64(end - start), first: timestamps[0], last: timestamps[len(timestamps)-1]}
+		payload := binary.AppendUvarint(nil, uint64(block.rows))
+		payload = binary.AppendVarint(payload, block.first)
+		payload = binary.AppendVarint(payload, block.last)
+		streams := [][]byte{encodeTimestamps(timestamps)}
+		for _, col := range columns {
+			streams = append(streams, encodeFloats(ts.Columns[col][start:end]))
+		}
+		for _, stream := range streams {
+			payload = append(binary.AppendUvarint(payload, uint64(len(stream))), stream...)
+		}
+		block.size = int64(len(payload))
+		buf := binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))
+		buf = append(buf, payload...)
+		buf = binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(payload))
+		if _, err := out.Write(buf); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+func writeBinaryIndex(out io.Writer, blocks []binaryBlock) error {
+	buf := make([]byte, 0, binaryEntrySize*len(blocks)+8)
+	for _, b := range blocks {
+		for _, v := range []int64{b.offset, b.size, b.rows, b.first, b.last} {
+			buf = binary.LittleEndian.AppendUint64(buf, uint64(v))
+		}
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(blocks)))
+	_, err := out.Write(append(buf, binaryIndexMagic...))
+	return err
+}
+
+//openBinary reads the header and the block index, scanning the blocks if the index is missing or torn
+func openBinary(r io.ReaderAt, size int64) (binaryFile, error) {
+	var file binaryFile
+	prefix := make([]byte, 9)
+	if _, err := r.ReadAt(prefix, 0); err != nil || string(prefix[:4]) != binaryMagic {
+		return file, fmt.Errorf("binary read failed: not a timeseries binary file")
+	}
+	if prefix[4] != binaryVersion {
+		return file, fmt.Errorf("binary read failed: unsupported version %d", prefix[4])
+	}
+	headerSize := int64(binary.LittleEndian.Uint32(prefix[5:]))
+	if headerSize > size-9 {
+		return file, fmt.Errorf("binary read failed: truncated header")
+	}
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, 9); err != nil {
+		return file, err
+	}
+	c := &binaryCursor{buf: header}
+	for n := c.uvarint(); n > 0 && c.err == nil; n-- {
+		file.columns = append(file.columns, c.str())
+	}
+	file.meta = make(map[string]string)
+	for n := c.uvarint(); n > 0 && c.err == nil; n-- {
+		k := c.str()
+		file.meta[k] = c.str()
+	}
+	file.timezone = c.str()
+	if c.err != nil {
+		return file, c.err
+	}
+	start := 9 + headerSize
+	if blocks, ok := readBinaryIndex(r, start, size); ok {
+		file.blocks = blocks
+		file.dataEnd = size - 8 - binaryEntrySize*int64(len(blocks))
+		return file, nil
+	}
+	logrus.Warnln("binary read warning: block index missing, scanning the blocks")
+	file.dataEnd = start
+	for file.dataEnd+8 <= size {
+		payload, err := readBinaryPayload(r, file.dataEnd, -1, size)
+		if err != nil {
+			break
+		}
+		c := &binaryCursor{buf: payload}
+		block := binaryBlock{offset: file.dataEnd, size: int64(len(payload)), rows: int64(c.uvarint()), first: c.varint(), last: c.varint()}
+		if c.err != nil {
+			break
+		}
+		file.blocks = append(file.blocks, block)
+		file.dataEnd += 8 + block.size
+	}
+	return file, nil
+}
+
+//readBinaryIndex reads the index at the end of the file, ok false if it is missing or invalid
+func readBinaryIndex(r io.ReaderAt, dataStart, size int64) ([]binaryBlock, bool) {
+	tail := make([]byte, 8)
+	if size-dataStart < 8 {
+		return nil, false
+	}
+	if _, err := r.ReadAt(tail, size-8); err != nil || string(tail[4:]) != binaryIndexMagic {
+		return nil, false
+	}
+	count := int64(binary.LittleEndian.Uint32(tail))
+	if binaryEntrySize*count > size-dataStart-8 {
+		return nil, false
+	}
+	buf := make([]byte, binaryEntrySize*count)
+	if _, err := r.ReadAt(buf, size-8-int64(len(buf))); err != nil {
+		return nil, false
+	}
+	blocks := make([]binaryBlock, count)
+	end := dataStart
+	for i := range blocks {
+		v := func(j int) int64 {
+			return int64(binary.LittleEndian.Uint64(buf[binaryEntrySize*i+8*j:]))
+		}
+		blocks[i] = binaryBlock{offset: v(0), size: v(1), rows: v(2), first: v(3), last: v(4)}
+		if blocks[i].offset != end || blocks[i].size < 0 {
+			return nil, false
+		}
+		end += 8 + blocks[i].size
+	}
+	return blocks, end == size-8-int64(len(buf))
+}
+
+//readBinaryPayload reads the payload of the block at offset and checks its crc, size -1 reads it from the block
+func readBinaryPayload(r io.ReaderAt, offset, size, fileSize int64) ([]byte, error) {
+	if size < 0 {
+		prefix := make([]byte, 4)
+		if _, err := r.ReadAt(prefix, offset); err != nil {
+			return nil, err
+		}
+		size = int64(binary.LittleEndian.Uint32(prefix))
+	}
+	if size > binaryMaxSize || offset+8+size > fileSize {
+		return nil, fmt.Errorf("binary read failed: block at %d past the end of the file", offset)
+	}
+	buf := make([]byte, size+8)
+	if _, err := r.ReadAt(buf, offset); err != nil {
+		return nil, err
+	}
+	payload := buf[4 : 4+size]
+	if int64(binary.LittleEndian.Uint32(buf)) != size || binary.LittleEndian.Uint32(buf[4+size:]) != crc32.ChecksumIEEE(payload) {
+		return nil, fmt.Errorf("binary read failed: corrupt block at %d", offset)
+	}
+	return payload, nil
+}
+
+//NewTimeSeriesFromBinary reads a file WriteAsBinary wrote, only the blocks within Start and End are read
+func NewTimeSeriesFromBinary(path string, options ...BinaryReadOptions) (TimeSeries, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return NewTimeSeries(), err
+	}
+	defer f.Close()
+	return NewTimeSeriesFromBinaryReader(f, options...)
+}
+
+//NewTimeSeriesFromBinaryReader reads the binary format from r as NewTimeSeriesFromBinary does. r is read
+//into memory unless it is an io.ReaderAt that can Seek, like an *os.File, which also skips the unneeded blocks
+func NewTimeSeriesFromBinaryReader(r io.Reader, options ...BinaryReadOptions) (TimeSeries, error) {
+	ra, size, err := readerAt(r)
+	if err != nil {
+		return NewTimeSeries(), err
+	}
+	return readBinary(ra, size, options)
+}
+
+func readBinary(r io.ReaderAt, size int64, options []BinaryReadOptions) (TimeSeries, error) {
+	var opts BinaryReadOptions
+	if options != nil {
+		opts = options[0]
+	}
+	ts := NewTimeSeries()
+	file, err := openBinary(r, size)
+	if err != nil {
+		return ts, err
+	}
+	for _, col := range opts.Columns {
+		if !aInB(col, file.columns) {
+			return ts, fmt.Errorf("binary read failed: no such column %s", col)
+		}
+	}
+	for _, col := range file.columns {
+		if opts.Columns == nil || aInB(col, opts.Columns) {
+			ts.Columns[col] = make([]float64, 0)
+		}
+	}
+	for k, v := range file.meta {
+		ts.Meta[k] = v
+	}
+	location, err := loadTimezone(file.timezone)
+	if err != nil {
+		logrus.Warnln("binary read warning: unknown timezone", file.timezone, "using UTC")
+	}
+	for _, block := range file.blocks {
+		if (!opts.Start.IsZero() && block.last < opts.Start.UnixNano()) || (!opts.End.IsZero() && block.first > opts.End.UnixNano()) {
+			continue
+		}
+		payload, err := readBinaryPayload(r, block.offset, block.size, size)
+		if err != nil {
+			return ts, err
+		}
+		c := &binaryCursor{buf: payload}
+		rows := int(c.uvarint())
+		c.varint()
+		c.varint()
+		if rows < 0 || rows > 8*len(payload)+2 {
+			return ts, fmt.Errorf("binary read failed: corrupt block at %d", block.offset)
+		}
+		timestamps, err := decodeTimestamps(c.bytes(), rows)
+		if c.err != nil {
+			return ts, c.err
+		}
+		if err != nil {
+			return ts, err
+		}
+		keep := make([]bool, rows)
+		for i, t := range timestamps {
+			if (!opts.Start.IsZero() && t < opts.Start.UnixNano()) || (!opts.End.IsZero() && t > opts.End.UnixNano()) {
+				continue
+			}
+			keep[i] = true
+			ts.Index = append(ts.Index, time.Unix(0, t).In(location))
+		}
+		for _, col := range file.columns {
+			stream := c.bytes()
+			if c.err != nil {
+				return ts, c.err
+			}
+			if _, ok := ts.Columns[col]; !ok {
+				continue
+			}
+			values, err := decodeFloats(stream, rows)
+			if err != nil {
+				return ts, err
+			}
+			for i, v := range values {
+				if keep[i] {
+					ts.Columns[col] = append(ts.Columns[col], v)
+				}
+			}
+		}
+	}
+	if !ts.IsEmpty() {
+		ts.changes = append(ts.changes, changelog{"load", ts.End(), ts.Start(), ts.End(), true})
+	}
+	return ts, nil
+}
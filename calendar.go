@@ -0,0 +1,134 @@
+package timeseries
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var regexCalendar, _ = regexp.Compile("^([0-9]*)(D|W|M|Q|Y)(-(MON|TUE|WED|THU|FRI|SAT|SUN))?$")
+
+var weekdays = map[string]time.Weekday{
+	"SUN": time.Sunday,
+	"MON": time.Monday,
+	"TUE": time.Tuesday,
+	"WED": time.Wednesday,
+	"THU": time.Thursday,
+	"FRI": time.Friday,
+	"SAT": time.Saturday,
+}
+
+//parseBins parses fixed or calendar intervals into bin functions
+//binStart returns the start of the bin a timestamp falls in, binEnd the end of a bin given its start.
+//fixed durations are laid on a grid anchored at origin, calendar units start at the calendar boundary
+func parseBins(interval string, origin time.Time) (binStart func(time.Time) time.Time, binEnd func(time.Time) time.Time, approx time.Duration, err error) {
+	if cal, ok := parseCalendarInterval(interval); ok {
+		return cal.floor, cal.next, cal.approx(), nil
+	}
+	duration, err := parseInterval(interval)
+	if err == nil && duration <= 0 {
+		err = fmt.Errorf("parsing interval %v failed.. interval must be positive", interval)
+	}
+	if err != nil {
+		return nil, nil, duration, err
+	}
+	binStart = func(t time.Time) time.Time {
+		offset := t.Sub(origin)
+		n := offset / duration
+		if offset < 0 && offset%duration != 0 {
+			n--
+		}
+		return origin.Add(n * duration)
+	}
+	binEnd = func(t time.Time) time.Time {
+		return t.Add(duration)
+	}
+	return binStart, binEnd, duration, nil
+}
+
+//calendarInterval bins by calendar boundaries instead of a fixed duration
+//boundaries are wall clock midnights in the location of the timestamp,
+//so month lengths and DST shifts are taken care of by time.Date
+type calendarInterval struct {
+	months    int
+	weeks     int
+	days      int
+	weekStart time.Weekday
+}
+
+//parseCalendarInterval parses calendar units
+//"1D" calendar day, "1W" or "W-MON" week starting on a weekday (default monday),
+//"1M" month, "1Q" quarter, "1Y" year. returns false if interval is not a calendar unit
+func parseCalendarInterval(interval string) (calendarInterval, bool) {
+	match := regexCalendar.FindStringSubmatch(strings.TrimSpace(interval))
+	if match == nil {
+		return calendarInterval{}, false
+	}
+	n := 1
+	if match[1] != "" {
+		n, _ = strconv.Atoi(match[1])
+	}
+	if n <= 0 {
+		return calendarInterval{}, false
+	}
+	if match[4] != "" && match[2] != "W" {
+		return calendarInterval{}, false
+	}
+	cal := calendarInterval{weekStart: time.Monday}
+	switch match[2] {
+	case "D":
+		cal.days = n
+	case "W":
+		cal.weeks = n
+		if match[4] != "" {
+			cal.weekStart = weekdays[match[4]]
+		}
+	case "M":
+		cal.months = n
+	case "Q":
+		cal.months = 3 * n
+	case "Y":
+		cal.months = 12 * n
+	}
+	return cal, true
+}
+
+//floor returns the start of the calendar bin t falls in
+func (c calendarInterval) floor(t time.Time) time.Time {
+	y, m, d := t.Date()
+	loc := t.Location()
+	switch {
+	case c.months > 0:
+		monthIndex := y*12 + int(m) - 1
+		monthIndex -= monthIndex % c.months
+		return time.Date(monthIndex/12, time.Month(monthIndex%12+1), 1, 0, 0, 0, 0, loc)
+	case c.weeks > 0:
+		day := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		day = day.AddDate(0, 0, -((int(day.Weekday()) - int(c.weekStart) + 7) % 7))
+		weeks := daysSinceEpoch(day) / 7
+		return day.AddDate(0, 0, -7*(((weeks%c.weeks)+c.weeks)%c.weeks))
+	default:
+		day := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		days := daysSinceEpoch(day)
+		return day.AddDate(0, 0, -(((days % c.days) + c.days) % c.days))
+	}
+}
+
+//next returns the start of the calendar bin following the one starting at t
+func (c calendarInterval) next(t time.Time) time.Time {
+	return t.AddDate(0, c.months, 7*c.weeks+c.days)
+}
+
+//approx is the nominal duration of the interval, only used for comparisons
+func (c calendarInterval) approx() time.Duration {
+	day := 24 * time.Hour
+	return time.Duration(c.months)*30*day + time.Duration(c.weeks)*7*day + time.Duration(c.days)*day
+}
+
+//daysSinceEpoch counts calendar days from 1970-01-01 to the date of t, ignoring its location offset
+func daysSinceEpoch(t time.Time) int {
+	y, m, d := t.Date()
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
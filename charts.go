@@ -0,0 +1,310 @@
+package timeseries
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+//HeikinAshi converts OHLC bars into Heikin-Ashi candles with columns open, high, low and close,
+//volume is carried over if present. the first candle opens at the midpoint of the first bar's open and close,
+//and so does the first candle after a bar with a NaN
+func (ts TimeSeries) HeikinAshi() (TimeSeries, error) {
+	if err := ts.requireColumns("heikin ashi", "open", "high", "low", "close"); err != nil {
+		return ts, err
+	}
+	o, h, l, c := ts.Columns["open"], ts.Columns["high"], ts.Columns["low"], ts.Columns["close"]
+	ha := NewTimeSeries()
+	ha.Index = append([]time.Time{}, ts.Index...)
+	haOpen, haHigh, haLow, haClose := make([]float64, ts.Length()), make([]float64, ts.Length()), make([]float64, ts.Length()), make([]float64, ts.Length())
+	for i := range ts.Index {
+		haClose[i] = (o[i] + h[i] + l[i] + c[i]) / 4
+		if i == 0 || math.IsNaN(haOpen[i-1]) || math.IsNaN(haClose[i-1]) {
+			haOpen[i] = (o[i] + c[i]) / 2
+		} else {
+			haOpen[i] = (haOpen[i-1] + haClose[i-1]) / 2
+		}
+		haHigh[i] = math.Max(h[i], math.Max(haOpen[i], haClose[i]))
+		haLow[i] = math.Min(l[i], math.Min(haOpen[i], haClose[i]))
+	}
+	ha.Columns["open"], ha.Columns["high"], ha.Columns["low"], ha.Columns["close"] = haOpen, haHigh, haLow, haClose
+	if volume, ok := ts.Columns["volume"]; ok {
+		ha.Columns["volume"] = append([]float64{}, volume...)
+	}
+	return ha, nil
+}
+
+//RenkoOptions sizes the bricks of Renko, either a fixed BrickSize or the ATR over ATRPeriod bars as known at each bar,
+//no bricks form before it is. Column is the price bricks are built from, default close
+type RenkoOptions struct {
+	BrickSize float64
+	ATRPeriod int
+	Column    string
+}
+
+//Renko converts prices into bricks with columns open, high, low, close and direction (1 up, -1 down).
+//a brick forms when the price moves a brick size past the last brick, reversals need two brick sizes.
+//each brick is timestamped at the bar which completes it, so a large move gives several rows with the same timestamp.
+//NaN and infinite prices are skipped, a bar moving more than renkoMaxBricks bricks is an error
+func (ts TimeSeries) Renko(options RenkoOptions) (TimeSeries, error) {
+	column := options.Column
+	if column == "" {
+		column = "close"
+	}
+	if err := ts.requireColumns("renko", column); err != nil {
+		return ts, err
+	}
+	size := options.BrickSize
+	if (size > 0) == (options.ATRPeriod > 0) {
+		return ts, fmt.Errorf("renko failed: set either a positive brick size or an ATR period")
+	}
+	var atr []float64
+	if options.ATRPeriod > 0 {
+		var err error
+		if atr, err = ts.averageTrueRange(options.ATRPeriod); err != nil {
+			return ts, err
+		}
+	}
+	bricks := newChart("open", "high", "low", "close", "direction")
+	prices := ts.Columns[column]
+	last, direction := math.NaN(), 0.0
+	for i, price := range prices {
+		if math.IsNaN(price) || math.IsInf(price, 0) {
+			continue
+		}
+		if math.IsNaN(last) {
+			last = price
+			continue
+		}
+		if atr != nil {
+			size = atr[i]
+		}
+		if !(size > 0) {
+			continue
+		}
+		for n := 0; ; n++ {
+			if n == renkoMaxBricks {
+				return ts, fmt.Errorf("renko failed: price %v at %v is over %d bricks of %v away", price, ts.Index[i], renkoMaxBricks, size)
+			}
+			var open, end float64
+			switch {
+			case direction >= 0 && price >= last+size:
+				open, end, direction = last, last+size, 1
+			case direction <= 0 && price <= last-size:
+				open, end, direction = last, last-size, -1
+			case direction > 0 && price <= last-2*size:
+				open, end, direction = last-size, last-2*size, -1
+			case direction < 0 && price >= last+2*size:
+				open, end, direction = last+size, last+2*size, 1
+			default:
+				open = math.NaN()
+			}
+			if math.IsNaN(open) {
+				break
+			}
+			bricks.add(ts.Index[i], open, math.Max(open, end), math.Min(open, end), end, direction)
+			last = end
+		}
+	}
+	return bricks.TimeSeries, nil
+}
+
+//KagiOptions sets the price move which reverses a Kagi line, Reversal is a price amount
+//or a percentage of the line's extreme if Percent is set. Column is the price used, default close
+type KagiOptions struct {
+	Reversal float64
+	Percent  bool
+	Column   string
+}
+
+//Kagi converts prices into Kagi lines with columns open, close, direction (1 up, -1 down) and
+//yang (1 thick, 0 thin). a line extends while the price moves its way and completes when the price
+//reverses by the reversal amount from its extreme, timestamped at that bar. lines turn yang
+//above the previous shoulder and yin below the previous waist. the line still being drawn is left out
+func (ts TimeSeries) Kagi(options KagiOptions) (TimeSeries, error) {
+	column := options.Column
+	if column == "" {
+		column = "close"
+	}
+	if err := ts.requireColumns("kagi", column); err != nil {
+		return ts, err
+	}
+	if options.Reversal <= 0 {
+		return ts, fmt.Errorf("kagi failed: reversal must be positive not %v", options.Reversal)
+	}
+	reversal := func(extreme float64) float64 {
+		if options.Percent {
+			return math.Abs(extreme) * options.Reversal / 100
+		}
+		return options.Reversal
+	}
+	lines := newChart("open", "close", "direction", "yang")
+	start, extreme, direction, yang := math.NaN(), math.NaN(), 0.0, 0.0
+	shoulder, waist := math.NaN(), math.NaN()
+	for i, price := range ts.Columns[column] {
+		if math.IsNaN(price) {
+			continue
+		}
+		if math.IsNaN(start) {
+			start, extreme = price, price
+			continue
+		}
+		if direction == 0 {
+			if math.Abs(price-start) >= reversal(start) {
+				extreme, direction = price, math.Copysign(1, price-start)
+				if direction > 0 {
+					yang = 1
+				}
+			}
+			continue
+		}
+		if (price-extreme)*direction > 0 {
+			extreme = price
+		} else if math.Abs(price-extreme) >= reversal(extreme) {
+			lines.add(ts.Index[i], start, extreme, direction, yang)
+			if direction > 0 {
+				shoulder = extreme
+			} else {
+				waist = extreme
+			}
+			start, extreme, direction = extreme, price, -direction
+		}
+		if direction > 0 && extreme > shoulder {
+			yang = 1
+		} else if direction < 0 && extreme < waist {
+			yang = 0
+		}
+	}
+	return lines.TimeSeries, nil
+}
+
+//PointAndFigureOptions sets the box size and the count of boxes which reverse a column, default 3.
+//HighLow extends columns with the high and reverses them with the low (or the other way in O columns),
+//otherwise Column is used for both, default close
+type PointAndFigureOptions struct {
+	BoxSize  float64
+	Reversal int
+	HighLow  bool
+	Column   string
+}
+
+//PointAndFigure converts prices into X (rising) and O (falling) columns with columns low, high,
+//direction (1 X, -1 O) and boxes. a column completes when the price reverses by Reversal boxes,
+//timestamped at that bar. the column still being drawn is left out
+func (ts TimeSeries) PointAndFigure(options PointAndFigureOptions) (TimeSeries, error) {
+	if options.BoxSize <= 0 {
+		return ts, fmt.Errorf("point and figure failed: box size must be positive not %v", options.BoxSize)
+	}
+	reversal := options.Reversal
+	if reversal == 0 {
+		reversal = 3
+	}
+	highs, lows := ts.Columns[options.Column], ts.Columns[options.Column]
+	if options.HighLow {
+		if err := ts.requireColumns("point and figure", "high", "low"); err != nil {
+			return ts, err
+		}
+		highs, lows = ts.Columns["high"], ts.Columns["low"]
+	} else {
+		if options.Column == "" {
+			highs, lows = ts.Columns["close"], ts.Columns["close"]
+		}
+		if highs == nil {
+			return ts, fmt.Errorf("point and figure failed: no such column %s", options.Column)
+		}
+	}
+	box := options.BoxSize
+	floor := func(price float64) float64 { return math.Floor(price/box) * box }
+	ceil := func(price float64) float64 { return math.Ceil(price/box) * box }
+	columns := newChart("low", "high", "direction", "boxes")
+	top, bottom, direction := math.NaN(), math.NaN(), 0.0
+	for i := range ts.Index {
+		high, low := highs[i], lows[i]
+		if math.IsNaN(high) || math.IsNaN(low) {
+			continue
+		}
+		if math.IsNaN(top) {
+			top, bottom = floor(high), ceil(low)
+			continue
+		}
+		switch {
+		case direction >= 0 && high >= top+box:
+			top, direction = floor(high), 1
+		case direction <= 0 && low <= bottom-box:
+			bottom, direction = ceil(low), -1
+		case direction > 0 && low <= top-float64(reversal)*box:
+			columns.add(ts.Index[i], bottom, top, direction, math.Round((top-bottom)/box)+1)
+			top, bottom, direction = top-box, ceil(low), -1
+		case direction < 0 && high >= bottom+float64(reversal)*box:
+			columns.add(ts.Index[i], bottom, top, direction, math.Round((top-bottom)/box)+1)
+			top, bottom, direction = floor(high), bottom+box, 1
+		}
+	}
+	return columns.TimeSeries, nil
+}
+
+//renkoMaxBricks caps the bricks a single bar adds to Renko
+const renkoMaxBricks = 10000
+
+//averageTrueRange is the Wilder ATR over period bars at every bar, using only that bar and the ones before.
+//it is NaN until period true ranges are known, bars with a NaN true range keep the previous ATR
+func (ts TimeSeries) averageTrueRange(period int) ([]float64, error) {
+	if err := ts.requireColumns("atr", "high", "low", "close"); err != nil {
+		return nil, err
+	}
+	if ts.Length() <= period {
+		return nil, fmt.Errorf("atr failed: need more than %d bars, found %d", period, ts.Length())
+	}
+	h, l, c := ts.Columns["high"], ts.Columns["low"], ts.Columns["close"]
+	atr := make([]float64, ts.Length())
+	atr[0] = math.NaN()
+	state, count := 0.0, 0
+	for i := 1; i < ts.Length(); i++ {
+		tr := math.Max(h[i]-l[i], math.Max(math.Abs(h[i]-c[i-1]), math.Abs(l[i]-c[i-1])))
+		if !math.IsNaN(tr) {
+			count++
+			if count <= period {
+				state += tr / float64(period)
+			} else {
+				state += (tr - state) / float64(period)
+			}
+		}
+		atr[i] = math.NaN()
+		if count >= period {
+			atr[i] = state
+		}
+	}
+	return atr, nil
+}
+
+//requireColumns returns an error naming the first missing column
+func (ts TimeSeries) requireColumns(name string, columns ...string) error {
+	for _, col := range columns {
+		if _, ok := ts.Columns[col]; !ok {
+			return fmt.Errorf("%s failed: no such column %s", name, col)
+		}
+	}
+	return nil
+}
+
+//chart is a `TimeSeries` built row by row with fixed columns
+type chart struct {
+	TimeSeries
+	columns []string
+}
+
+func newChart(columns ...string) chart {
+	c := chart{TimeSeries: NewTimeSeries(), columns: columns}
+	for _, col := range columns {
+		c.Columns[col] = make([]float64, 0)
+	}
+	return c
+}
+
+//add a row, values in the order of the chart columns
+func (c *chart) add(t time.Time, values ...float64) {
+	c.Index = append(c.Index, t)
+	for i, col := range c.columns {
+		c.Columns[col] = append(c.Columns[col], values[i])
+	}
+}
@@ -0,0 +1,352 @@
+package timeseries
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//csvTimeLayouts are tried in order when CSVOptions has no TimeFormat or TimeUnit,
+//they cover RFC3339 and what WriteAsCSV writes
+var csvTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+//CSVOptions configures NewCSVReader
+type CSVOptions struct {
+	//Delimiter between fields, default ','
+	Delimiter rune
+	//Comment starts a line that is skipped, default none
+	Comment rune
+	//SkipRows are skipped before the header, or before the data with NoHeader
+	SkipRows int
+	//NoHeader reads the first row as data, columns are then named column0, column1...
+	NoHeader bool
+	//Index is the name of the timestamp column. default the first column whose name contains date or time,
+	//column0 with NoHeader
+	Index string
+	//TimeFormat is the layout of the timestamps as for time.Parse, default RFC3339 or 2006-01-02 15:04:05
+	TimeFormat string
+	//TimeUnit reads the timestamps as numbers since the unix epoch, in "s", "ms", "us" or "ns"
+	TimeUnit string
+	//Location of timestamps without a zone, and of the Index, default UTC
+	Location *time.Location
+	//Columns to load, default all
+	Columns []string
+	//Exclude are columns not loaded
+	Exclude []string
+	//Decimal separator of the numbers, default '.'. with any other, a number holding a '.' fails to parse
+	Decimal rune
+	//Strict stops at the first cell that fails to parse or row of the wrong width. otherwise such cells
+	//load as NaN, rows with a bad timestamp are skipped and every failure is reported in CSVErrors
+	Strict bool
+}
+
+//CSVError is a cell or a row that failed to parse. Line is 1 based as in the input, Column the 0 based field,
+//-1 when the row itself could not be read
+type CSVError struct {
+	Line   int
+	Column int
+	Name   string
+	Value  string
+	Err    error
+}
+
+func (e CSVError) Error() string {
+	if e.Column < 0 {
+		return fmt.Sprintf("csv parse failed at line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("csv parse failed at line %d column %d (%s) value %q: %v", e.Line, e.Column, e.Name, e.Value, e.Err)
+}
+
+func (e CSVError) Unwrap() error {
+	return e.Err
+}
+
+//CSVErrors are the failures of a lenient read, the TimeSeries returned with them holds everything else
+type CSVErrors []CSVError
+
+func (e CSVErrors) Error() string {
+	if len(e) == 0 {
+		return "no csv parse failures"
+	}
+	return fmt.Sprintf("%d csv parse failures, first: %v", len(e), e[0])
+}
+
+//CSVReader reads a CSV one row at a time, use it to stream large files into a BarBuilder or similar
+type CSVReader struct {
+	reader   *csv.Reader
+	options  CSVOptions
+	header   []string
+	index    int
+	fields   []int //of the columns loaded
+	pending  []string
+	line     int
+	errors   CSVErrors
+	location *time.Location
+	unit     time.Duration
+}
+
+//NewCSVReader reads the header of r and resolves the columns to load
+func NewCSVReader(r io.Reader, options ...CSVOptions) (*CSVReader, error) {
+	var opts CSVOptions
+	if options != nil {
+		opts = options[0]
+	}
+	c := &CSVReader{reader: csv.NewReader(r), options: opts, location: opts.Location}
+	if c.location == nil {
+		c.location = time.UTC
+	}
+	if opts.Delimiter != 0 {
+		c.reader.Comma = opts.Delimiter
+	}
+	c.reader.Comment = opts.Comment
+	c.reader.FieldsPerRecord = -1
+	if opts.Decimal != 0 && opts.Decimal == c.reader.Comma {
+		return nil, fmt.Errorf("csv read failed: decimal separator and delimiter are both %q", opts.Decimal)
+	}
+	if opts.TimeUnit != "" {
+		units := map[string]time.Duration{"s": time.Second, "ms": time.Millisecond, "us": time.Microsecond, "ns": time.Nanosecond}
+		if c.unit = units[opts.TimeUnit]; c.unit == 0 {
+			return nil, fmt.Errorf("csv read failed: time unit must be s, ms, us or ns not %s", opts.TimeUnit)
+		}
+	}
+	for i := 0; i < opts.SkipRows; i++ {
+		if _, err := c.reader.Read(); err == io.EOF {
+			return c, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	record, err := c.reader.Read()
+	if err == io.EOF {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.header = make([]string, len(record))
+	for i, name := range record {
+		c.header[i] = strings.TrimSpace(name)
+		if opts.NoHeader {
+			c.header[i] = "column" + strconv.Itoa(i)
+		}
+	}
+	if opts.NoHeader {
+		c.pending = record
+		c.line, _ = c.reader.FieldPos(0)
+	}
+	c.index = -1
+	for i, name := range c.header {
+		lower := strings.ToLower(name)
+		if name == opts.Index || (opts.Index == "" && (strings.Contains(lower, "date") || strings.Contains(lower, "time"))) {
+			c.index = i
+			break
+		}
+	}
+	if c.index < 0 && opts.Index == "" && opts.NoHeader {
+		c.index = 0
+	}
+	if c.index < 0 && opts.Index == "" {
+		return nil, fmt.Errorf("csv read failed: no date or time column for the index in %v", c.header)
+	}
+	if c.index < 0 {
+		return nil, fmt.Errorf("csv read failed: no index column %s in %v", opts.Index, c.header)
+	}
+	for _, col := range opts.Columns {
+		if !aInB(col, c.header) {
+			return nil, fmt.Errorf("csv read failed: no such column %s", col)
+		}
+	}
+	for i, name := range c.header {
+		if i != c.index && (opts.Columns == nil || aInB(name, opts.Columns)) && !aInB(name, opts.Exclude) {
+			c.fields = append(c.fields, i)
+		}
+	}
+	return c, nil
+}
+
+//Columns are the names of the columns Read loads
+func (c *CSVReader) Columns() []string {
+	names := make([]string, len(c.fields))
+	for i, field := range c.fields {
+		names[i] = c.header[field]
+	}
+	return names
+}
+
+//Errors are the failures a lenient reader skipped so far
+func (c *CSVReader) Errors() CSVErrors {
+	return c.errors
+}
+
+//Read returns the next row, io.EOF after the last one
+func (c *CSVReader) Read() (DataPoint, error) {
+	for {
+		record, line := c.pending, c.line
+		c.pending = nil
+		if record == nil {
+			if c.header == nil {
+				return DataPoint{}, io.EOF
+			}
+			var err error
+			record, err = c.reader.Read()
+			if err == io.EOF {
+				return DataPoint{}, io.EOF
+			}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				if err := c.fail(CSVError{Line: parseErr.Line, Column: -1, Err: parseErr.Err}); err != nil {
+					return DataPoint{}, err
+				}
+				continue
+			}
+			if err != nil {
+				return DataPoint{}, err
+			}
+			line, _ = c.reader.FieldPos(0)
+		}
+		dp, ok, err := c.parse(record, line)
+		if err != nil || ok {
+			return dp, err
+		}
+	}
+}
+
+//fail returns the error in strict mode, else keeps it for Errors
+func (c *CSVReader) fail(e CSVError) error {
+	if c.options.Strict {
+		return e
+	}
+	logrus.Warnln(e)
+	c.errors = append(c.errors, e)
+	return nil
+}
+
+//parse converts a row, ok is false for rows a lenient reader skips
+func (c *CSVReader) parse(record []string, line int) (DataPoint, bool, error) {
+	dp := DataPoint{Columns: make(map[string]float64, len(c.fields))}
+	if len(record) != len(c.header) {
+		e := CSVError{Line: line, Column: -1, Err: fmt.Errorf("row has %d fields, the header %d", len(record), len(c.header))}
+		if err := c.fail(e); err != nil {
+			return dp, false, err
+		}
+	}
+	if c.index >= len(record) {
+		return dp, false, nil
+	}
+	t, err := c.parseTime(record[c.index])
+	if err != nil {
+		e := CSVError{Line: line, Column: c.index, Name: c.header[c.index], Value: record[c.index], Err: err}
+		return dp, false, c.fail(e)
+	}
+	dp.Index = t
+	for _, field := range c.fields {
+		name := c.header[field]
+		if field >= len(record) {
+			dp.Columns[name] = math.NaN()
+			continue
+		}
+		v, err := c.parseFloat(record[field])
+		if err != nil {
+			v = math.NaN()
+			if err := c.fail(CSVError{Line: line, Column: field, Name: name, Value: record[field], Err: err}); err != nil {
+				return dp, false, err
+			}
+		}
+		dp.Columns[name] = v
+	}
+	return dp, true, nil
+}
+
+//parseFloat reads a number with the Decimal separator
+func (c *CSVReader) parseFloat(value string) (float64, error) {
+	if c.options.Decimal != 0 && c.options.Decimal != '.' {
+		if strings.Contains(value, ".") {
+			return math.NaN(), fmt.Errorf("'.' in a number with decimal separator %q", c.options.Decimal)
+		}
+		value = strings.Replace(value, string(c.options.Decimal), ".", 1)
+	}
+	return parseFloat(value)
+}
+
+//parseTime reads a timestamp per TimeUnit or TimeFormat, else tries csvTimeLayouts
+func (c *CSVReader) parseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case c.unit != 0:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Unix(0, 0).Add(time.Duration(n) * c.unit).In(c.location), nil
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("not a number of %s since the epoch", c.options.TimeUnit)
+		}
+		return time.Unix(0, int64(f*float64(c.unit))).In(c.location), nil
+	case c.options.TimeFormat != "":
+		t, err := time.ParseInLocation(c.options.TimeFormat, value, c.location)
+		return t.In(c.location), err
+	}
+	for _, layout := range csvTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, c.location); err == nil {
+			return t.In(c.location), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown timestamp format")
+}
+
+//NewTimeSeriesFromCSVReader streams a CSV into a TimeSeries, see CSVOptions. a lenient read that skipped
+//failures returns them as CSVErrors along with the TimeSeries of everything else
+func NewTimeSeriesFromCSVReader(r io.Reader, options ...CSVOptions) (TimeSeries, error) {
+	ts := NewTimeSeries()
+	reader, err := NewCSVReader(r, options...)
+	if err != nil {
+		return ts, err
+	}
+	for _, col := range reader.Columns() {
+		ts.Columns[col] = make([]float64, 0)
+	}
+	for {
+		dp, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ts, err
+		}
+		ts.Index = append(ts.Index, dp.Index)
+		for col, v := range dp.Columns {
+			ts.Columns[col] = append(ts.Columns[col], v)
+		}
+	}
+	if !ts.IsEmpty() {
+		ts.changes = append(ts.changes, changelog{"load", ts.End(), ts.Start(), ts.End(), true})
+	}
+	if errs := reader.Errors(); len(errs) > 0 {
+		return ts, errs
+	}
+	return ts, nil
+}
+
+//NewTimeSeriesFromCSVFile streams the CSV file at path into a TimeSeries, see NewTimeSeriesFromCSVReader
+func NewTimeSeriesFromCSVFile(path string, options ...CSVOptions) (TimeSeries, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return NewTimeSeries(), err
+	}
+	defer f.Close()
+	return NewTimeSeriesFromCSVReader(f, options...)
+}
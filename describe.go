@@ -0,0 +1,162 @@
+package timeseries
+
+import (
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+)
+
+//ColumnSummary holds descriptive statistics of a single column, NaN values are excluded
+//Std is the sample standard deviation, Skew and Kurtosis are the bias corrected sample skewness
+//and excess kurtosis. First and Last are the timestamps of the first and last non NaN value
+type ColumnSummary struct {
+	Column   string
+	Count    int
+	NaNCount int
+	Mean     float64
+	Std      float64
+	Min      float64
+	Q25      float64
+	Median   float64
+	Q75      float64
+	Max      float64
+	Skew     float64
+	Kurtosis float64
+	First    time.Time
+	Last     time.Time
+}
+
+//Description is the summary of a `TimeSeries` returned by Describe
+//Frequency is the most common interval between consecutive timestamps
+type Description struct {
+	Rows      int
+	Start     time.Time
+	End       time.Time
+	Frequency time.Duration
+	Columns   []ColumnSummary
+}
+
+//Describe summarizes every column, sorted by column name
+func (ts TimeSeries) Describe() Description {
+	d := Description{Rows: ts.Length(), Frequency: ts.inferFrequency()}
+	if !ts.IsEmpty() {
+		d.Start, d.End = ts.Start(), ts.End()
+	}
+	columns := ts.ListColumns()
+	sort.Strings(columns)
+	for _, col := range columns {
+		d.Columns = append(d.Columns, ts.describeColumn(col))
+	}
+	return d
+}
+
+//describeColumn computes the summary of one column
+func (ts TimeSeries) describeColumn(col string) ColumnSummary {
+	values := ts.Columns[col]
+	clean := dropNA(values)
+	s := ColumnSummary{Column: col, Count: len(clean), NaNCount: len(values) - len(clean)}
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		if s.First.IsZero() {
+			s.First = ts.Index[i]
+		}
+		s.Last = ts.Index[i]
+	}
+	nan := math.NaN()
+	s.Mean, s.Std, s.Min, s.Q25, s.Median, s.Q75, s.Max, s.Skew, s.Kurtosis = nan, nan, nan, nan, nan, nan, nan, nan, nan
+	n := float64(len(clean))
+	if len(clean) == 0 {
+		return s
+	}
+	sorted := append([]float64{}, clean...)
+	sort.Float64s(sorted)
+	s.Min, s.Max = sorted[0], sorted[len(sorted)-1]
+	s.Q25, s.Median, s.Q75 = quantileOfSorted(sorted, 0.25), quantileOfSorted(sorted, 0.5), quantileOfSorted(sorted, 0.75)
+	sum := 0.0
+	for _, v := range clean {
+		sum += v
+	}
+	s.Mean = sum / n
+	var m2, m3, m4 float64
+	for _, v := range clean {
+		d := v - s.Mean
+		m2 += d * d
+		m3 += d * d * d
+		m4 += d * d * d * d
+	}
+	m2, m3, m4 = m2/n, m3/n, m4/n
+	if len(clean) >= 2 {
+		s.Std = math.Sqrt(m2 * n / (n - 1))
+	}
+	if len(clean) >= 3 && m2 != 0 {
+		s.Skew = math.Sqrt(n*(n-1)) / (n - 2) * m3 / math.Pow(m2, 1.5)
+	}
+	if len(clean) >= 4 && m2 != 0 {
+		g2 := m4/(m2*m2) - 3
+		s.Kurtosis = ((n+1)*g2 + 6) * (n - 1) / ((n - 2) * (n - 3))
+	}
+	return s
+}
+
+//inferFrequency returns the most common interval between consecutive timestamps,
+//the smallest one on ties. 0 if less than 2 rows
+func (ts TimeSeries) inferFrequency() time.Duration {
+	counts := make(map[time.Duration]int)
+	for i := 1; i < ts.Length(); i++ {
+		counts[ts.Index[i].Sub(ts.Index[i-1])]++
+	}
+	var frequency time.Duration
+	best := 0
+	for interval, count := range counts {
+		if count > best || (count == best && interval < frequency) {
+			frequency, best = interval, count
+		}
+	}
+	return frequency
+}
+
+//Print renders the description as a table with a column per `TimeSeries` column
+func (d Description) Print() {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetStyle(table.StyleLight)
+	titles := table.Row{""}
+	for _, c := range d.Columns {
+		titles = append(titles, c.Column)
+	}
+	t.AppendHeader(titles)
+	stats := []struct {
+		name  string
+		value func(ColumnSummary) interface{}
+	}{
+		{"count", func(c ColumnSummary) interface{} { return c.Count }},
+		{"nan", func(c ColumnSummary) interface{} { return c.NaNCount }},
+		{"mean", func(c ColumnSummary) interface{} { return c.Mean }},
+		{"std", func(c ColumnSummary) interface{} { return c.Std }},
+		{"min", func(c ColumnSummary) interface{} { return c.Min }},
+		{"25%", func(c ColumnSummary) interface{} { return c.Q25 }},
+		{"50%", func(c ColumnSummary) interface{} { return c.Median }},
+		{"75%", func(c ColumnSummary) interface{} { return c.Q75 }},
+		{"max", func(c ColumnSummary) interface{} { return c.Max }},
+		{"skew", func(c ColumnSummary) interface{} { return c.Skew }},
+		{"kurtosis", func(c ColumnSummary) interface{} { return c.Kurtosis }},
+		{"first", func(c ColumnSummary) interface{} { return c.First }},
+		{"last", func(c ColumnSummary) interface{} { return c.Last }},
+	}
+	for _, stat := range stats {
+		row := table.Row{stat.name}
+		for _, c := range d.Columns {
+			row = append(row, stat.value(c))
+		}
+		t.AppendRow(row)
+	}
+	t.AppendSeparator()
+	t.AppendRow(table.Row{"rows", d.Rows})
+	t.AppendRow(table.Row{"frequency", d.Frequency})
+	t.Render()
+}
@@ -0,0 +1,212 @@
+package timeseries
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+//EWM is an exponentially weighted window over a `TimeSeries`, build it with TimeSeries.EWM
+//every operation returns a copy of the `TimeSeries` with a new column per input column
+//named column_operation_decay, for ex:- close_ewm_mean_span20
+type EWM struct {
+	ts         TimeSeries
+	alpha      float64
+	halflife   time.Duration
+	label      string
+	minPeriods int
+	err        error
+}
+
+//EWM creates an exponentially weighted window. by can be
+//"span" (alpha = 2/(span+1)), "com" (alpha = 1/(1+com)), "alpha", or "halflife" (alpha = 1-exp(-ln2/halflife)).
+//a halflife given as a duration string like "5m" decays by the time elapsed between rows, for irregular indexes.
+//values are computed recursively, y = alpha*x + (1-alpha)*y, starting from the first non NaN value.
+//minPeriods is the min count of non NaN values seen to produce a value, default 1
+func (ts TimeSeries) EWM(by string, value interface{}, minPeriods ...int) EWM {
+	e := EWM{ts: ts, minPeriods: 1}
+	if minPeriods != nil {
+		e.minPeriods = minPeriods[0]
+	}
+	if by == "halflife" {
+		if s, ok := value.(string); ok {
+			e.halflife, e.err = parseInterval(s)
+			e.label = "halflife" + s
+			if e.err == nil && e.halflife <= 0 {
+				e.err = fmt.Errorf("ewm failed: halflife must be positive not %s", s)
+			}
+			return e
+		}
+	}
+	var param float64
+	switch value.(type) {
+	case int:
+		param = float64(value.(int))
+	case float64:
+		param = value.(float64)
+	default:
+		e.err = fmt.Errorf("ewm failed: invalid type for %s `%T`", by, value)
+		return e
+	}
+	e.label = by + fmt.Sprint(param)
+	switch by {
+	case "span":
+		if param < 1 {
+			e.err = fmt.Errorf("ewm failed: span must be at least 1 not %v", param)
+		}
+		e.alpha = 2 / (param + 1)
+	case "com":
+		if param < 0 {
+			e.err = fmt.Errorf("ewm failed: com must be positive not %v", param)
+		}
+		e.alpha = 1 / (1 + param)
+	case "alpha":
+		if param <= 0 || param > 1 {
+			e.err = fmt.Errorf("ewm failed: alpha must be in (0, 1] not %v", param)
+		}
+		e.alpha = param
+	case "halflife":
+		if param <= 0 {
+			e.err = fmt.Errorf("ewm failed: halflife must be positive not %v", param)
+		}
+		e.alpha = 1 - math.Exp(-math.Ln2/param)
+	default:
+		e.err = fmt.Errorf("ewm failed: by must be span, com, alpha or halflife not %s", by)
+	}
+	return e
+}
+
+//Mean is the exponentially weighted moving average
+func (e EWM) Mean(columns ...string) (TimeSeries, error) {
+	return e.apply("ewm_mean", columns)
+}
+
+//Var is the exponentially weighted (biased) variance
+func (e EWM) Var(columns ...string) (TimeSeries, error) {
+	return e.apply("ewm_var", columns)
+}
+
+//Std is the exponentially weighted (biased) standard deviation
+func (e EWM) Std(columns ...string) (TimeSeries, error) {
+	return e.apply("ewm_std", columns)
+}
+
+//apply runs the recursive mean and variance over each column
+func (e EWM) apply(name string, columns []string) (TimeSeries, error) {
+	if e.err != nil {
+		return e.ts, e.err
+	}
+	if columns == nil {
+		columns = e.ts.ListColumns()
+	}
+	weighted := e.ts.shallowCopy()
+	for _, col := range columns {
+		values, ok := e.ts.Columns[col]
+		if !ok {
+			return e.ts, fmt.Errorf("ewm failed: no such column %s", col)
+		}
+		result := make([]float64, len(values))
+		var mean, variance float64
+		var last time.Time
+		count := 0
+		for i, v := range values {
+			if math.IsNaN(v) {
+				result[i] = math.NaN()
+				continue
+			}
+			if count == 0 {
+				mean, variance = v, 0
+			} else {
+				alpha := e.alpha
+				if e.halflife != 0 {
+					alpha = 1 - math.Exp(-math.Ln2*float64(e.ts.Index[i].Sub(last))/float64(e.halflife))
+				}
+				diff := v - mean
+				increment := alpha * diff
+				mean += increment
+				variance = (1 - alpha) * (variance + diff*increment)
+			}
+			last = e.ts.Index[i]
+			count++
+			switch {
+			case count < e.minPeriods:
+				result[i] = math.NaN()
+			case name == "ewm_mean":
+				result[i] = mean
+			case count < 2:
+				result[i] = math.NaN()
+			case name == "ewm_var":
+				result[i] = variance
+			default:
+				result[i] = math.Sqrt(variance)
+			}
+		}
+		weighted.Columns[col+"_"+name+"_"+e.label] = result
+	}
+	return weighted, nil
+}
+
+//Expanding creates a window growing from the first row, it supports every `Rolling` aggregation
+//new columns are named column_aggregation_expanding. minPeriods default 1
+func (ts TimeSeries) Expanding(minPeriods ...int) Rolling {
+	r := Rolling{ts: ts, rows: ts.Length(), label: "expanding", minPeriods: 1}
+	if r.rows == 0 {
+		r.rows = 1
+	}
+	if minPeriods != nil {
+		r.minPeriods = minPeriods[0]
+	}
+	return r
+}
+
+//CumSum adds a cumulative sum column per column provided, named column_cumsum. NaN values are skipped
+func (ts TimeSeries) CumSum(columns ...string) (TimeSeries, error) {
+	return ts.cumulative("cumsum", func(acc, v float64) float64 { return acc + v }, columns)
+}
+
+//CumProd adds a cumulative product column per column provided, named column_cumprod
+func (ts TimeSeries) CumProd(columns ...string) (TimeSeries, error) {
+	return ts.cumulative("cumprod", func(acc, v float64) float64 { return acc * v }, columns)
+}
+
+//CumMax adds a running maximum column per column provided, named column_cummax
+func (ts TimeSeries) CumMax(columns ...string) (TimeSeries, error) {
+	return ts.cumulative("cummax", math.Max, columns)
+}
+
+//CumMin adds a running minimum column per column provided, named column_cummin
+func (ts TimeSeries) CumMin(columns ...string) (TimeSeries, error) {
+	return ts.cumulative("cummin", math.Min, columns)
+}
+
+//cumulative reduces each column continuously, keeping every intermediate value
+//NaN rows stay NaN and do not reset the accumulator
+func (ts TimeSeries) cumulative(name string, fn func(float64, float64) float64, columns []string) (TimeSeries, error) {
+	if columns == nil {
+		columns = ts.ListColumns()
+	}
+	accumulated := ts.shallowCopy()
+	for _, col := range columns {
+		values, ok := ts.Columns[col]
+		if !ok {
+			return ts, fmt.Errorf("%s failed: no such column %s", name, col)
+		}
+		result := make([]float64, len(values))
+		var acc float64
+		started := false
+		for i, v := range values {
+			if math.IsNaN(v) {
+				result[i] = math.NaN()
+				continue
+			}
+			if !started {
+				acc, started = v, true
+			} else {
+				acc = fn(acc, v)
+			}
+			result[i] = acc
+		}
+		accumulated.Columns[col+"_"+name] = result
+	}
+	return accumulated, nil
+}
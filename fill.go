@@ -0,0 +1,144 @@
+package timeseries
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+//FillNA fills missing values. strategy can be either
+//a float64 constant, a string {"ffill", "bfill", "linear", "nearest"} applied to all columns,
+//or a map[string]string of column:strategy where a numeric string is used as a constant.
+//linear interpolates by time between the neighbouring values, nearest takes the value closest in time.
+//limit is the max consecutive NaNs filled per gap, default no limit. ffill, linear and constants fill
+//the start of a gap, bfill the end of a gap, nearest fills within limit rows of a value
+func (ts TimeSeries) FillNA(strategy interface{}, limit ...int) (TimeSeries, error) {
+	var maxFill int
+	if limit != nil {
+		maxFill = limit[0]
+	}
+	strategyMap := make(map[string]string)
+	switch strategy.(type) {
+	case float64:
+		for _, col := range ts.ListColumns() {
+			strategyMap[col] = strconv.FormatFloat(strategy.(float64), 'g', -1, 64)
+		}
+	case int:
+		for _, col := range ts.ListColumns() {
+			strategyMap[col] = strconv.Itoa(strategy.(int))
+		}
+	case string:
+		for _, col := range ts.ListColumns() {
+			strategyMap[col] = strategy.(string)
+		}
+	case map[string]string:
+		strategyMap = strategy.(map[string]string)
+	default:
+		return ts, fmt.Errorf("fillna failed: invalid type for strategy `%T`", strategy)
+	}
+	filled := NewTimeSeries()
+	filled.Index = append(filled.Index, ts.Index...)
+	filled.MaxSize = ts.MaxSize
+	for k, v := range ts.Meta {
+		filled.Meta[k] = v
+	}
+	for col, values := range ts.Columns {
+		s, ok := strategyMap[col]
+		if !ok {
+			filled.Columns[col] = append([]float64{}, values...)
+			continue
+		}
+		column, err := fillColumn(ts.Index, values, s, maxFill)
+		if err != nil {
+			return ts, fmt.Errorf("fillna failed for column `%s`: %v", col, err)
+		}
+		filled.Columns[col] = column
+	}
+	return filled, nil
+}
+
+//fillColumn returns a filled copy of values, limit 0 means no limit
+func fillColumn(index []time.Time, values []float64, strategy string, limit int) ([]float64, error) {
+	var constant float64
+	switch strategy {
+	case "ffill", "bfill", "linear", "nearest":
+	default:
+		var err error
+		if constant, err = strconv.ParseFloat(strategy, 64); err != nil {
+			return nil, fmt.Errorf("no such fill strategy %s", strategy)
+		}
+	}
+	filled := append([]float64{}, values...)
+	if limit <= 0 {
+		limit = len(values)
+	}
+	for _, gap := range findGaps(values) {
+		start, end := gap[0], gap[1] //NaNs are values[start:end]
+		before, after := start-1, end
+		hasBefore, hasAfter := before >= 0, after < len(values)
+		switch strategy {
+		case "ffill":
+			if !hasBefore {
+				continue
+			}
+			for i := start; i < end && i-start < limit; i++ {
+				filled[i] = values[before]
+			}
+		case "bfill":
+			if !hasAfter {
+				continue
+			}
+			for i := end - 1; i >= start && end-i <= limit; i-- {
+				filled[i] = values[after]
+			}
+		case "linear":
+			if !hasBefore || !hasAfter {
+				continue
+			}
+			span := float64(index[after].Sub(index[before]))
+			for i := start; i < end && i-start < limit; i++ {
+				weight := float64(index[i].Sub(index[before])) / span
+				filled[i] = values[before] + (values[after]-values[before])*weight
+			}
+		case "nearest":
+			for i := start; i < end; i++ {
+				useBefore := hasBefore && i-before <= limit
+				useAfter := hasAfter && after-i <= limit
+				if useBefore && useAfter {
+					if index[after].Sub(index[i]) < index[i].Sub(index[before]) {
+						useBefore = false
+					} else {
+						useAfter = false
+					}
+				}
+				if useBefore {
+					filled[i] = values[before]
+				} else if useAfter {
+					filled[i] = values[after]
+				}
+			}
+		default:
+			for i := start; i < end && i-start < limit; i++ {
+				filled[i] = constant
+			}
+		}
+	}
+	return filled, nil
+}
+
+//findGaps returns [start, end) pairs of consecutive NaN runs in values
+func findGaps(values []float64) [][2]int {
+	gaps := make([][2]int, 0)
+	for i := 0; i < len(values); i++ {
+		if !math.IsNaN(values[i]) {
+			continue
+		}
+		start := i
+		for i < len(values) && math.IsNaN(values[i]) {
+			i++
+		}
+		gaps = append(gaps, [2]int{start, i})
+	}
+	return gaps
+}
@@ -0,0 +1,284 @@
+package timeseries
+
+import (
+	"encoding/binary"
+)
+
+//flatTable is a flatbuffers table to encode, fields are set by their id in the schema
+type flatTable struct {
+	fields []flatField
+}
+
+//flatField is either inline bytes, for scalars and structs, or an offset to child
+type flatField struct {
+	inline []byte
+	align  int
+	child  flatObject
+}
+
+//flatObject is anything a table field or a vector can point at
+type flatObject interface {
+	encode(b *flatBuilder) int
+}
+
+func (t *flatTable) set(id int, f flatField) *flatTable {
+	for len(t.fields) <= id {
+		t.fields = append(t.fields, flatField{})
+	}
+	t.fields[id] = f
+	return t
+}
+
+func (t *flatTable) uint8(id int, v uint8) *flatTable {
+	return t.set(id, flatField{inline: []byte{v}, align: 1})
+}
+
+func (t *flatTable) boolean(id int, v bool) *flatTable {
+	if v {
+		return t.uint8(id, 1)
+	}
+	return t.uint8(id, 0)
+}
+
+func (t *flatTable) int16(id int, v int16) *flatTable {
+	return t.set(id, flatField{inline: binary.LittleEndian.AppendUint16(nil, uint16(v)), align: 2})
+}
+
+func (t *flatTable) int32(id int, v int32) *flatTable {
+	return t.set(id, flatField{inline: binary.LittleEndian.AppendUint32(nil, uint32(v)), align: 4})
+}
+
+func (t *flatTable) int64(id int, v int64) *flatTable {
+	return t.set(id, flatField{inline: binary.LittleEndian.AppendUint64(nil, uint64(v)), align: 8})
+}
+
+func (t *flatTable) object(id int, o flatObject) *flatTable {
+	return t.set(id, flatField{child: o, align: 4})
+}
+
+//flatString is a string child
+type flatString string
+
+//flatVector is a vector of tables or strings
+type flatVector []flatObject
+
+//flatStructs is a vector of inline structs of size bytes each
+type flatStructs struct {
+	data  []byte
+	size  int
+	align int
+}
+
+//flatBuilder encodes front to back, children always follow the offsets pointing at them
+type flatBuilder struct {
+	buf []byte
+}
+
+//encodeFlatBuffer encodes root, the result is padded to 8 bytes
+func encodeFlatBuffer(root *flatTable) []byte {
+	b := &flatBuilder{buf: make([]byte, 4, 256)}
+	b.uoffset(0, root.encode(b))
+	b.pad(8)
+	return b.buf
+}
+
+func (b *flatBuilder) pad(align int) {
+	for len(b.buf)%align != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *flatBuilder) uint16(v int) {
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(v))
+}
+
+func (b *flatBuilder) uint32(v int) {
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(v))
+}
+
+//uoffset points the offset at position at to target
+func (b *flatBuilder) uoffset(at, target int) {
+	binary.LittleEndian.PutUint32(b.buf[at:], uint32(target-at))
+}
+
+//encode writes the vtable, then the table, then its children. inline fields are laid out largest
+//alignment first after the offset to the vtable
+func (t *flatTable) encode(b *flatBuilder) int {
+	offsets := make([]int, len(t.fields))
+	size, align := 4, 4
+	for _, a := range []int{8, 4, 2, 1} {
+		for id, f := range t.fields {
+			if f.align != a || (f.inline == nil && f.child == nil) {
+				continue
+			}
+			for size%a != 0 {
+				size++
+			}
+			offsets[id] = size
+			size += len(f.inline)
+			if f.child != nil {
+				size += 4
+			}
+			if a > align {
+				align = a
+			}
+		}
+	}
+	b.pad(2)
+	vtable := len(b.buf)
+	b.uint16(4 + 2*len(t.fields))
+	b.uint16(size)
+	for _, o := range offsets {
+		b.uint16(o)
+	}
+	b.pad(align)
+	table := len(b.buf)
+	b.buf = append(b.buf, make([]byte, size)...)
+	binary.LittleEndian.PutUint32(b.buf[table:], uint32(int32(table-vtable)))
+	for id, f := range t.fields {
+		copy(b.buf[table+offsets[id]:], f.inline)
+	}
+	for id, f := range t.fields {
+		if f.child != nil {
+			b.uoffset(table+offsets[id], f.child.encode(b))
+		}
+	}
+	return table
+}
+
+func (s flatString) encode(b *flatBuilder) int {
+	b.pad(4)
+	pos := len(b.buf)
+	b.uint32(len(s))
+	b.buf = append(append(b.buf, s...), 0)
+	return pos
+}
+
+func (v flatVector) encode(b *flatBuilder) int {
+	b.pad(4)
+	pos := len(b.buf)
+	b.uint32(len(v))
+	b.buf = append(b.buf, make([]byte, 4*len(v))...)
+	for i, o := range v {
+		b.uoffset(pos+4+4*i, o.encode(b))
+	}
+	return pos
+}
+
+func (s flatStructs) encode(b *flatBuilder) int {
+	align := s.align
+	if align < 4 {
+		align = 4
+	}
+	for (len(b.buf)+4)%align != 0 {
+		b.buf = append(b.buf, 0)
+	}
+	pos := len(b.buf)
+	n := 0
+	if s.size > 0 {
+		n = len(s.data) / s.size
+	}
+	b.uint32(n)
+	b.buf = append(b.buf, s.data...)
+	return pos
+}
+
+//flatView reads a table of an encoded flatbuffer. it does not check bounds, callers recover from the
+//panics of malformed input
+type flatView struct {
+	buf []byte
+	pos int
+}
+
+//flatRoot is the root table of buf
+func flatRoot(buf []byte) flatView {
+	return flatView{buf: buf, pos: int(binary.LittleEndian.Uint32(buf))}
+}
+
+//field is the position of field id, 0 if it is absent
+func (v flatView) field(id int) int {
+	vtable := v.pos - int(int32(binary.LittleEndian.Uint32(v.buf[v.pos:])))
+	if 4+2*id >= int(binary.LittleEndian.Uint16(v.buf[vtable:])) {
+		return 0
+	}
+	o := int(binary.LittleEndian.Uint16(v.buf[vtable+4+2*id:]))
+	if o == 0 {
+		return 0
+	}
+	return v.pos + o
+}
+
+func (v flatView) uint8(id int, def uint8) uint8 {
+	if p := v.field(id); p != 0 {
+		return v.buf[p]
+	}
+	return def
+}
+
+func (v flatView) boolean(id int) bool {
+	return v.uint8(id, 0) != 0
+}
+
+func (v flatView) int16(id int, def int16) int16 {
+	if p := v.field(id); p != 0 {
+		return int16(binary.LittleEndian.Uint16(v.buf[p:]))
+	}
+	return def
+}
+
+func (v flatView) int32(id int, def int32) int32 {
+	if p := v.field(id); p != 0 {
+		return int32(binary.LittleEndian.Uint32(v.buf[p:]))
+	}
+	return def
+}
+
+func (v flatView) int64(id int, def int64) int64 {
+	if p := v.field(id); p != 0 {
+		return int64(binary.LittleEndian.Uint64(v.buf[p:]))
+	}
+	return def
+}
+
+//deref follows the offset at position p
+func (v flatView) deref(p int) int {
+	return p + int(binary.LittleEndian.Uint32(v.buf[p:]))
+}
+
+func (v flatView) table(id int) (flatView, bool) {
+	p := v.field(id)
+	if p == 0 {
+		return flatView{}, false
+	}
+	return flatView{buf: v.buf, pos: v.deref(p)}, true
+}
+
+func (v flatView) str(id int) string {
+	p := v.field(id)
+	if p == 0 {
+		return ""
+	}
+	p = v.deref(p)
+	n := int(binary.LittleEndian.Uint32(v.buf[p:]))
+	return string(v.buf[p+4 : p+4+n])
+}
+
+//vector is the position of the first element of vector id and its length
+func (v flatView) vector(id int) (int, int) {
+	p := v.field(id)
+	if p == 0 {
+		return 0, 0
+	}
+	p = v.deref(p)
+	return p + 4, int(binary.LittleEndian.Uint32(v.buf[p:]))
+}
+
+//tables reads a vector of tables
+func (v flatView) tables(id int) []flatView {
+	start, n := v.vector(id)
+	tables := make([]flatView, n)
+	for i := range tables {
+		tables[i] = flatView{buf: v.buf, pos: v.deref(start + 4*i)}
+	}
+	return tables
+}
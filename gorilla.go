@@ -0,0 +1,224 @@
+package timeseries
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+//bitWriter appends bits most significant first
+type bitWriter struct {
+	buf  []byte
+	free int //bits free in the last byte
+}
+
+func (w *bitWriter) writeBits(v uint64, n int) {
+	for n > 0 {
+		if w.free == 0 {
+			w.buf = append(w.buf, 0)
+			w.free = 8
+		}
+		take := n
+		if take > w.free {
+			take = w.free
+		}
+		chunk := v >> (n - take) & (1<<take - 1)
+		w.buf[len(w.buf)-1] |= byte(chunk << (w.free - take))
+		w.free -= take
+		n -= take
+	}
+}
+
+//bitReader reads what bitWriter wrote
+type bitReader struct {
+	buf []byte
+	pos int //in bits
+}
+
+func (r *bitReader) readBits(n int) (uint64, error) {
+	if r.pos+n > 8*len(r.buf) {
+		return 0, fmt.Errorf("gorilla decode failed: unexpected end of data")
+	}
+	var v uint64
+	for n > 0 {
+		free := 8 - r.pos%8
+		take := n
+		if take > free {
+			take = free
+		}
+		chunk := uint64(r.buf[r.pos/8]) >> (free - take) & (1<<take - 1)
+		v = v<<take | chunk
+		r.pos += take
+		n -= take
+	}
+	return v, nil
+}
+
+//ones counts the leading 1 bits, reading at most max bits
+func (r *bitReader) ones(max int) (int, error) {
+	for n := 0; n < max; n++ {
+		bit, err := r.readBits(1)
+		if err != nil || bit == 0 {
+			return n, err
+		}
+	}
+	return max, nil
+}
+
+//gorillaBuckets are the bit widths of the delta of delta, picked by the count of leading 1 bits of the control code
+var gorillaBuckets = []int{0, 7, 9, 12, 32, 64}
+
+//encodeTimestamps encodes timestamps as in Facebook's Gorilla: the first one and the first delta in full,
+//then the delta of delta in the smallest bucket it fits, a single 0 bit for regular intervals
+func encodeTimestamps(timestamps []int64) []byte {
+	w := &bitWriter{}
+	var delta int64
+	for i, t := range timestamps {
+		switch i {
+		case 0:
+			w.writeBits(uint64(t), 64)
+			continue
+		case 1:
+			delta = t - timestamps[0]
+			w.writeBits(uint64(delta), 64)
+			continue
+		}
+		dod := t - timestamps[i-1] - delta
+		delta = t - timestamps[i-1]
+		for code, width := range gorillaBuckets {
+			if width != 64 && (width == 0 && dod != 0 || width > 0 && (dod < -1<<(width-1) || dod >= 1<<(width-1))) {
+				continue
+			}
+			if code == len(gorillaBuckets)-1 {
+				w.writeBits(1<<code-1, code) //the last code needs no terminating 0
+			} else {
+				w.writeBits(1<<(code+1)-2, code+1)
+			}
+			w.writeBits(uint64(dod), width)
+			break
+		}
+	}
+	return w.buf
+}
+
+//decodeTimestamps decodes n timestamps encodeTimestamps encoded
+func decodeTimestamps(data []byte, n int) ([]int64, error) {
+	r := &bitReader{buf: data}
+	out := make([]int64, n)
+	var delta int64
+	for i := range out {
+		if i < 2 {
+			v, err := r.readBits(64)
+			if err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				out[0] = int64(v)
+			} else {
+				delta = int64(v)
+				out[1] = out[0] + delta
+			}
+			continue
+		}
+		code, err := r.ones(len(gorillaBuckets) - 1)
+		if err != nil {
+			return nil, err
+		}
+		width := gorillaBuckets[code]
+		v, err := r.readBits(width)
+		if err != nil {
+			return nil, err
+		}
+		dod := int64(v)
+		if width > 0 && width < 64 { //sign extend
+			dod = int64(v<<(64-width)) >> (64 - width)
+		}
+		delta += dod
+		out[i] = out[i-1] + delta
+	}
+	return out, nil
+}
+
+//encodeFloats encodes values as in Facebook's Gorilla: the first one in full, then the XOR with the previous value,
+//a single 0 bit when unchanged, else its meaningful bits within the previous window or a new one. lossless, NaN included
+func encodeFloats(values []float64) []byte {
+	w := &bitWriter{}
+	var previous uint64
+	leading, trailing, window := 0, 0, false
+	for i, v := range values {
+		b := math.Float64bits(v)
+		xor := b ^ previous
+		previous = b
+		switch {
+		case i == 0:
+			w.writeBits(b, 64)
+		case xor == 0:
+			w.writeBits(0, 1)
+		default:
+			lz, tz := bits.LeadingZeros64(xor), bits.TrailingZeros64(xor)
+			if lz > 31 {
+				lz = 31
+			}
+			if window && lz >= leading && tz >= trailing {
+				w.writeBits(2, 2)
+				w.writeBits(xor>>trailing, 64-leading-trailing)
+				continue
+			}
+			leading, trailing, window = lz, tz, true
+			w.writeBits(3, 2)
+			w.writeBits(uint64(lz), 5)
+			w.writeBits(uint64(64-lz-tz)&63, 6) //64 meaningful bits is written as 0
+			w.writeBits(xor>>tz, 64-lz-tz)
+		}
+	}
+	return w.buf
+}
+
+//decodeFloats decodes n values encodeFloats encoded
+func decodeFloats(data []byte, n int) ([]float64, error) {
+	r := &bitReader{buf: data}
+	out := make([]float64, n)
+	var previous uint64
+	leading, trailing := 0, 0
+	for i := range out {
+		if i == 0 {
+			v, err := r.readBits(64)
+			if err != nil {
+				return nil, err
+			}
+			previous = v
+			out[0] = math.Float64frombits(v)
+			continue
+		}
+		code, err := r.ones(2)
+		if err != nil {
+			return nil, err
+		}
+		if code == 2 {
+			lz, err := r.readBits(5)
+			if err != nil {
+				return nil, err
+			}
+			size, err := r.readBits(6)
+			if err != nil {
+				return nil, err
+			}
+			if size == 0 {
+				size = 64
+			}
+			if int(lz+size) > 64 {
+				return nil, fmt.Errorf("gorilla decode failed: invalid window")
+			}
+			leading, trailing = int(lz), 64-int(lz+size)
+		}
+		if code > 0 {
+			v, err := r.readBits(64 - leading - trailing)
+			if err != nil {
+				return nil, err
+			}
+			previous ^= v << trailing
+		}
+		out[i] = math.Float64frombits(previous)
+	}
+	return out, nil
+}
@@ -0,0 +1,135 @@
+//Package indicators computes technical indicators over OHLCV `TimeSeries`.
+//every indicator returns a copy of the `TimeSeries` with its result appended as named columns,
+//for ex:- SMA(ts, 20) adds "sma_20". rows before an indicator has enough data (warm-up) are NaN.
+//the window indicators, SMA, WMA, BollingerBands, Stochastic and the range volatilities, are NaN wherever
+//their window holds a NaN input. the smoothed ones, EMA, MACD, RSI, ATR and ADX, and the running OBV and VWAP
+//skip NaN instead: the rows a NaN input touches are NaN and the next rows carry on from the ones before
+package indicators
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/leedstyh/timeseries-go/internal/series"
+)
+
+//sourceColumn picks the column an indicator runs on, default close
+func sourceColumn(column []string) string {
+	if column == nil {
+		return "close"
+	}
+	return column[0]
+}
+
+//requirePeriods errors if any of periods is not positive
+func requirePeriods(periods ...int) error {
+	for _, p := range periods {
+		if p <= 0 {
+			return fmt.Errorf("indicator failed: period must be positive not %d", p)
+		}
+	}
+	return nil
+}
+
+//sma is the simple moving average, NaN for the first period-1 rows
+func sma(values []float64, period int) []float64 {
+	out := series.NaN(len(values))
+	sum, nans := 0.0, 0
+	for i, v := range values {
+		if math.IsNaN(v) {
+			nans++
+		} else {
+			sum += v
+		}
+		if i >= period {
+			if math.IsNaN(values[i-period]) {
+				nans--
+			} else {
+				sum -= values[i-period]
+			}
+		}
+		if i >= period-1 && nans == 0 {
+			out[i] = sum / float64(period)
+		}
+	}
+	return out
+}
+
+//smooth is an exponential average with the given alpha, seeded by the simple average
+//of the first period non NaN values. leading NaNs are skipped, later NaN rows stay NaN
+func smooth(values []float64, period int, alpha float64) []float64 {
+	out := series.NaN(len(values))
+	var state float64
+	sum, count := 0.0, 0
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		count++
+		switch {
+		case count < period:
+			sum += v
+			continue
+		case count == period:
+			state = (sum + v) / float64(period)
+		default:
+			state = alpha*v + (1-alpha)*state
+		}
+		out[i] = state
+	}
+	return out
+}
+
+//ema is the exponential moving average with alpha 2/(period+1)
+func ema(values []float64, period int) []float64 {
+	return smooth(values, period, 2/float64(period+1))
+}
+
+//wilder is Wilder's smoothing, an exponential average with alpha 1/period
+func wilder(values []float64, period int) []float64 {
+	return smooth(values, period, 1/float64(period))
+}
+
+//wma is the linearly weighted moving average, the latest value weighs period
+func wma(values []float64, period int) []float64 {
+	out := series.NaN(len(values))
+	denominator := float64(period*(period+1)) / 2
+	for i := period - 1; i < len(values); i++ {
+		sum := 0.0
+		for j := 0; j < period; j++ {
+			sum += values[i-period+1+j] * float64(j+1)
+		}
+		out[i] = sum / denominator
+	}
+	return out
+}
+
+//highest and lowest return the max and min of the window ending at each row
+func highest(values []float64, period int) []float64 {
+	return extreme(values, period, math.Max)
+}
+
+func lowest(values []float64, period int) []float64 {
+	return extreme(values, period, math.Min)
+}
+
+func extreme(values []float64, period int, fn func(float64, float64) float64) []float64 {
+	out := series.NaN(len(values))
+	for i := period - 1; i < len(values); i++ {
+		e := values[i]
+		for j := i - period + 1; j < i; j++ {
+			e = fn(e, values[j])
+		}
+		out[i] = e
+	}
+	return out
+}
+
+//trueRange is the max of high-low and the gaps from the previous close, NaN at the first row
+func trueRange(high, low, closes []float64) []float64 {
+	tr := series.NaN(len(closes))
+	for i := 1; i < len(closes); i++ {
+		tr[i] = math.Max(high[i]-low[i], math.Max(math.Abs(high[i]-closes[i-1]), math.Abs(low[i]-closes[i-1])))
+	}
+	return tr
+}
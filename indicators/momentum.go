@@ -0,0 +1,88 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+
+	timeseries "github.com/leedstyh/timeseries-go"
+	"github.com/leedstyh/timeseries-go/internal/series"
+)
+
+//RSI appends the relative strength index of column (default close) as "rsi_<period>"
+//gains and losses are averaged with Wilder's smoothing, the first period rows are NaN.
+//a window without losses is 100, a window without gains or losses is 50
+func RSI(ts timeseries.TimeSeries, period int, column ...string) (timeseries.TimeSeries, error) {
+	col := sourceColumn(column)
+	if err := series.Require(ts, "indicator", col); err != nil {
+		return ts, err
+	}
+	if err := requirePeriods(period); err != nil {
+		return ts, err
+	}
+	values := ts.Columns[col]
+	gains := series.NaN(len(values))
+	losses := series.NaN(len(values))
+	for i := 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+		gains[i], losses[i] = math.Max(change, 0), math.Max(-change, 0)
+		if math.IsNaN(change) {
+			gains[i], losses[i] = math.NaN(), math.NaN()
+		}
+	}
+	avgGain := wilder(gains, period)
+	avgLoss := wilder(losses, period)
+	rsi := series.NaN(len(values))
+	for i := range rsi {
+		rsi[i] = rsiOf(avgGain[i], avgLoss[i])
+	}
+	return series.With(ts, map[string][]float64{
+		fmt.Sprintf("rsi_%d", period): rsi,
+	}), nil
+}
+
+//rsiOf converts average gain and loss to the rsi
+func rsiOf(avgGain, avgLoss float64) float64 {
+	switch {
+	case math.IsNaN(avgGain) || math.IsNaN(avgLoss):
+		return math.NaN()
+	case avgGain == 0 && avgLoss == 0:
+		return 50
+	case avgLoss == 0:
+		return 100
+	}
+	return 100 - 100/(1+avgGain/avgLoss)
+}
+
+//Stochastic appends the stochastic oscillator "stoch_k" and its simple average "stoch_d"
+//%K is 100*(close-lowest low)/(highest high-lowest low) over kPeriod rows, NaN for the first kPeriod-1 rows,
+//%D is NaN for the first kPeriod+dPeriod-2 rows. a flat window is 50
+func Stochastic(ts timeseries.TimeSeries, kPeriod, dPeriod int) (timeseries.TimeSeries, error) {
+	if err := series.Require(ts, "indicator", "high", "low", "close"); err != nil {
+		return ts, err
+	}
+	if err := requirePeriods(kPeriod, dPeriod); err != nil {
+		return ts, err
+	}
+	highs := highest(ts.Columns["high"], kPeriod)
+	lows := lowest(ts.Columns["low"], kPeriod)
+	closes := ts.Columns["close"]
+	k := series.NaN(ts.Length())
+	for i := range k {
+		k[i] = stochasticOf(closes[i], highs[i], lows[i])
+	}
+	return series.With(ts, map[string][]float64{
+		"stoch_k": k,
+		"stoch_d": sma(k, dPeriod),
+	}), nil
+}
+
+//stochasticOf places price within the high low range, 0 to 100
+func stochasticOf(price, high, low float64) float64 {
+	if high == low {
+		if math.IsNaN(price) {
+			return math.NaN()
+		}
+		return 50
+	}
+	return 100 * (price - low) / (high - low)
+}
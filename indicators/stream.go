@@ -0,0 +1,569 @@
+package indicators
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	timeseries "github.com/leedstyh/timeseries-go"
+)
+
+//Streamer is a stateful indicator fed one `DataPoint` (bar) at a time, for live data.
+//Update returns the indicator values for the bar under the same column names the batch function appends,
+//so after n updates the values equal row n of the batch computation.
+//every streamer is a plain struct with exported fields which can be saved with encoding/json
+//and restored by unmarshalling into the same type, so a restarted process resumes without replaying history.
+//periods must be positive, as in the batch functions, and Update errors on restored state with one that is not.
+//a bar missing a column the indicator needs is an error, as in the batch functions, and leaves the state unchanged
+type Streamer interface {
+	Update(dp timeseries.DataPoint) (map[string]float64, error)
+}
+
+//requireFields errors if any of columns is missing in dp
+func requireFields(dp timeseries.DataPoint, columns ...string) error {
+	for _, col := range columns {
+		if _, ok := dp.Columns[col]; !ok {
+			return fmt.Errorf("indicator failed: no such column %s", col)
+		}
+	}
+	return nil
+}
+
+//SMAStream is the streaming version of SMA
+type SMAStream struct {
+	Column string `json:"column"`
+	Window window `json:"window"`
+}
+
+//NewSMAStream creates a streaming SMA of column (default close)
+func NewSMAStream(period int, column ...string) (*SMAStream, error) {
+	if err := requirePeriods(period); err != nil {
+		return nil, err
+	}
+	return &SMAStream{Column: sourceColumn(column), Window: newWindow(period)}, nil
+}
+
+//Update with the next bar
+func (s *SMAStream) Update(dp timeseries.DataPoint) (map[string]float64, error) {
+	if err := requirePeriods(s.Window.Size); err != nil {
+		return nil, err
+	}
+	if err := requireFields(dp, s.Column); err != nil {
+		return nil, err
+	}
+	s.Window.push(dp.Columns[s.Column])
+	return map[string]float64{fmt.Sprintf("sma_%d", s.Window.Size): s.Window.mean()}, nil
+}
+
+//EMAStream is the streaming version of EMA
+type EMAStream struct {
+	Column   string   `json:"column"`
+	Smoother smoother `json:"smoother"`
+}
+
+//NewEMAStream creates a streaming EMA of column (default close)
+func NewEMAStream(period int, column ...string) (*EMAStream, error) {
+	if err := requirePeriods(period); err != nil {
+		return nil, err
+	}
+	return &EMAStream{Column: sourceColumn(column), Smoother: newSmoother(period, 2/float64(period+1))}, nil
+}
+
+//Update with the next bar
+func (s *EMAStream) Update(dp timeseries.DataPoint) (map[string]float64, error) {
+	if err := requirePeriods(s.Smoother.Period); err != nil {
+		return nil, err
+	}
+	if err := requireFields(dp, s.Column); err != nil {
+		return nil, err
+	}
+	return map[string]float64{fmt.Sprintf("ema_%d", s.Smoother.Period): s.Smoother.update(dp.Columns[s.Column])}, nil
+}
+
+//WMAStream is the streaming version of WMA
+type WMAStream struct {
+	Column string `json:"column"`
+	Window window `json:"window"`
+}
+
+//NewWMAStream creates a streaming WMA of column (default close)
+func NewWMAStream(period int, column ...string) (*WMAStream, error) {
+	if err := requirePeriods(period); err != nil {
+		return nil, err
+	}
+	return &WMAStream{Column: sourceColumn(column), Window: newWindow(period)}, nil
+}
+
+//Update with the next bar
+func (s *WMAStream) Update(dp timeseries.DataPoint) (map[string]float64, error) {
+	if err := requirePeriods(s.Window.Size); err != nil {
+		return nil, err
+	}
+	if err := requireFields(dp, s.Column); err != nil {
+		return nil, err
+	}
+	s.Window.push(dp.Columns[s.Column])
+	value := math.NaN()
+	if s.Window.full() {
+		value = wma(s.Window.Values, s.Window.Size)[s.Window.Size-1]
+	}
+	return map[string]float64{fmt.Sprintf("wma_%d", s.Window.Size): value}, nil
+}
+
+//RSIStream is the streaming version of RSI
+type RSIStream struct {
+	Column   string   `json:"column"`
+	Previous previous `json:"previous"`
+	Gains    smoother `json:"gains"`
+	Losses   smoother `json:"losses"`
+}
+
+//NewRSIStream creates a streaming RSI of column (default close)
+func NewRSIStream(period int, column ...string) (*RSIStream, error) {
+	if err := requirePeriods(period); err != nil {
+		return nil, err
+	}
+	return &RSIStream{Column: sourceColumn(column), Gains: newSmoother(period, 1/float64(period)), Losses: newSmoother(period, 1/float64(period))}, nil
+}
+
+//Update with the next bar
+func (s *RSIStream) Update(dp timeseries.DataPoint) (map[string]float64, error) {
+	if err := requirePeriods(s.Gains.Period, s.Losses.Period); err != nil {
+		return nil, err
+	}
+	if err := requireFields(dp, s.Column); err != nil {
+		return nil, err
+	}
+	value := dp.Columns[s.Column]
+	gain, loss := math.NaN(), math.NaN()
+	if s.Previous.Seen {
+		change := value - float64(s.Previous.Value)
+		gain, loss = math.Max(change, 0), math.Max(-change, 0)
+		if math.IsNaN(change) {
+			gain, loss = math.NaN(), math.NaN()
+		}
+	}
+	s.Previous.set(value)
+	return map[string]float64{fmt.Sprintf("rsi_%d", s.Gains.Period): rsiOf(s.Gains.update(gain), s.Losses.update(loss))}, nil
+}
+
+//MACDStream is the streaming version of MACD
+type MACDStream struct {
+	Column string   `json:"column"`
+	Fast   smoother `json:"fast"`
+	Slow   smoother `json:"slow"`
+	Signal smoother `json:"signal"`
+}
+
+//NewMACDStream creates a streaming MACD of column (default close)
+func NewMACDStream(fast, slow, signal int, column ...string) (*MACDStream, error) {
+	if err := requirePeriods(fast, slow, signal); err != nil {
+		return nil, err
+	}
+	return &MACDStream{
+		Column: sourceColumn(column),
+		Fast:   newSmoother(fast, 2/float64(fast+1)),
+		Slow:   newSmoother(slow, 2/float64(slow+1)),
+		Signal: newSmoother(signal, 2/float64(signal+1)),
+	}, nil
+}
+
+//Update with the next bar
+func (s *MACDStream) Update(dp timeseries.DataPoint) (map[string]float64, error) {
+	if err := requirePeriods(s.Fast.Period, s.Slow.Period, s.Signal.Period); err != nil {
+		return nil, err
+	}
+	if err := requireFields(dp, s.Column); err != nil {
+		return nil, err
+	}
+	value := dp.Columns[s.Column]
+	macd := s.Fast.update(value) - s.Slow.update(value)
+	signal := s.Signal.update(macd)
+	return map[string]float64{
+		"macd":        macd,
+		"macd_signal": signal,
+		"macd_hist":   macd - signal,
+	}, nil
+}
+
+//BollingerStream is the streaming version of BollingerBands
+type BollingerStream struct {
+	Column string  `json:"column"`
+	K      float64 `json:"k"`
+	Window window  `json:"window"`
+}
+
+//NewBollingerStream creates streaming bollinger bands of column (default close)
+func NewBollingerStream(period int, k float64, column ...string) (*BollingerStream, error) {
+	if err := requirePeriods(period); err != nil {
+		return nil, err
+	}
+	return &BollingerStream{Column: sourceColumn(column), K: k, Window: newWindow(period)}, nil
+}
+
+//Update with the next bar
+func (s *BollingerStream) Update(dp timeseries.DataPoint) (map[string]float64, error) {
+	if err := requirePeriods(s.Window.Size); err != nil {
+		return nil, err
+	}
+	if err := requireFields(dp, s.Column); err != nil {
+		return nil, err
+	}
+	s.Window.push(dp.Columns[s.Column])
+	middle := s.Window.mean()
+	upper, lower := math.NaN(), math.NaN()
+	if !math.IsNaN(middle) {
+		variance := 0.0
+		for _, v := range s.Window.Values {
+			variance += (v - middle) * (v - middle)
+		}
+		std := math.Sqrt(variance / float64(s.Window.Size))
+		upper, lower = middle+s.K*std, middle-s.K*std
+	}
+	return map[string]float64{
+		"bb_middle": middle,
+		"bb_upper":  upper,
+		"bb_lower":  lower,
+	}, nil
+}
+
+//ATRStream is the streaming version of ATR
+type ATRStream struct {
+	Close    previous `json:"close"`
+	Smoother smoother `json:"smoother"`
+}
+
+//NewATRStream creates a streaming ATR
+func NewATRStream(period int) (*ATRStream, error) {
+	if err := requirePeriods(period); err != nil {
+		return nil, err
+	}
+	return &ATRStream{Smoother: newSmoother(period, 1/float64(period))}, nil
+}
+
+//Update with the next bar
+func (s *ATRStream) Update(dp timeseries.DataPoint) (map[string]float64, error) {
+	if err := requirePeriods(s.Smoother.Period); err != nil {
+		return nil, err
+	}
+	if err := requireFields(dp, "high", "low", "close"); err != nil {
+		return nil, err
+	}
+	tr := s.Close.trueRange(dp)
+	return map[string]float64{fmt.Sprintf("atr_%d", s.Smoother.Period): s.Smoother.update(tr)}, nil
+}
+
+//StochasticStream is the streaming version of Stochastic
+type StochasticStream struct {
+	Highs window `json:"highs"`
+	Lows  window `json:"lows"`
+	K     window `json:"k"`
+}
+
+//NewStochasticStream creates a streaming stochastic oscillator
+func NewStochasticStream(kPeriod, dPeriod int) (*StochasticStream, error) {
+	if err := requirePeriods(kPeriod, dPeriod); err != nil {
+		return nil, err
+	}
+	return &StochasticStream{Highs: newWindow(kPeriod), Lows: newWindow(kPeriod), K: newWindow(dPeriod)}, nil
+}
+
+//Update with the next bar
+func (s *StochasticStream) Update(dp timeseries.DataPoint) (map[string]float64, error) {
+	if err := requirePeriods(s.Highs.Size, s.Lows.Size, s.K.Size); err != nil {
+		return nil, err
+	}
+	if err := requireFields(dp, "high", "low", "close"); err != nil {
+		return nil, err
+	}
+	s.Highs.push(dp.Columns["high"])
+	s.Lows.push(dp.Columns["low"])
+	k := math.NaN()
+	if s.Highs.full() {
+		k = stochasticOf(dp.Columns["close"], s.Highs.extreme(math.Max), s.Lows.extreme(math.Min))
+	}
+	s.K.push(k)
+	return map[string]float64{
+		"stoch_k": k,
+		"stoch_d": s.K.mean(),
+	}, nil
+}
+
+//ADXStream is the streaming version of ADX
+type ADXStream struct {
+	High    previous `json:"high"`
+	Low     previous `json:"low"`
+	Close   previous `json:"close"`
+	TR      smoother `json:"tr"`
+	PlusDM  smoother `json:"plus_dm"`
+	MinusDM smoother `json:"minus_dm"`
+	ADX     smoother `json:"adx"`
+}
+
+//NewADXStream creates a streaming ADX
+func NewADXStream(period int) (*ADXStream, error) {
+	if err := requirePeriods(period); err != nil {
+		return nil, err
+	}
+	alpha := 1 / float64(period)
+	return &ADXStream{
+		TR:      newSmoother(period, alpha),
+		PlusDM:  newSmoother(period, alpha),
+		MinusDM: newSmoother(period, alpha),
+		ADX:     newSmoother(period, alpha),
+	}, nil
+}
+
+//Update with the next bar
+func (s *ADXStream) Update(dp timeseries.DataPoint) (map[string]float64, error) {
+	if err := requirePeriods(s.TR.Period, s.PlusDM.Period, s.MinusDM.Period, s.ADX.Period); err != nil {
+		return nil, err
+	}
+	if err := requireFields(dp, "high", "low", "close"); err != nil {
+		return nil, err
+	}
+	high, low := dp.Columns["high"], dp.Columns["low"]
+	plusDM, minusDM := math.NaN(), math.NaN()
+	if s.High.Seen {
+		up, down := high-float64(s.High.Value), float64(s.Low.Value)-low
+		plusDM, minusDM = 0, 0
+		if up > down && up > 0 {
+			plusDM = up
+		}
+		if down > up && down > 0 {
+			minusDM = down
+		}
+		if math.IsNaN(up) || math.IsNaN(down) {
+			plusDM, minusDM = math.NaN(), math.NaN()
+		}
+	}
+	s.High.set(high)
+	s.Low.set(low)
+	tr := s.TR.update(s.Close.trueRange(dp))
+	smoothPlus, smoothMinus := s.PlusDM.update(plusDM), s.MinusDM.update(minusDM)
+	plusDI, minusDI, dx := math.NaN(), math.NaN(), math.NaN()
+	if tr != 0 {
+		plusDI, minusDI = 100*smoothPlus/tr, 100*smoothMinus/tr
+		if plusDI+minusDI == 0 {
+			dx = 0
+		} else {
+			dx = 100 * math.Abs(plusDI-minusDI) / (plusDI + minusDI)
+		}
+	}
+	period := s.ADX.Period
+	return map[string]float64{
+		fmt.Sprintf("adx_%d", period):      s.ADX.update(dx),
+		fmt.Sprintf("plus_di_%d", period):  plusDI,
+		fmt.Sprintf("minus_di_%d", period): minusDI,
+	}, nil
+}
+
+//OBVStream is the streaming version of OBV
+type OBVStream struct {
+	Running  float64  `json:"running"`
+	Previous previous `json:"previous"`
+}
+
+//NewOBVStream creates a streaming OBV
+func NewOBVStream() *OBVStream {
+	return &OBVStream{}
+}
+
+//Update with the next bar
+func (s *OBVStream) Update(dp timeseries.DataPoint) (map[string]float64, error) {
+	if err := requireFields(dp, "close", "volume"); err != nil {
+		return nil, err
+	}
+	price, volume := dp.Columns["close"], dp.Columns["volume"]
+	if math.IsNaN(price) || math.IsNaN(volume) {
+		return map[string]float64{"obv": math.NaN()}, nil
+	}
+	if s.Previous.Seen {
+		s.Running += obvStep(float64(s.Previous.Value), price, volume)
+	}
+	s.Previous.set(price)
+	return map[string]float64{"obv": s.Running}, nil
+}
+
+//VWAPStream is the streaming version of VWAP
+type VWAPStream struct {
+	Daily       bool      `json:"daily"`
+	Last        time.Time `json:"last"`
+	PriceVolume float64   `json:"price_volume"`
+	TotalVolume float64   `json:"total_volume"`
+}
+
+//NewVWAPStream creates a streaming VWAP, daily resets it at every calendar date
+func NewVWAPStream(daily ...bool) *VWAPStream {
+	return &VWAPStream{Daily: daily != nil && daily[0]}
+}
+
+//Update with the next bar
+func (s *VWAPStream) Update(dp timeseries.DataPoint) (map[string]float64, error) {
+	if err := requireFields(dp, "high", "low", "close", "volume"); err != nil {
+		return nil, err
+	}
+	if s.Daily && !s.Last.IsZero() {
+		y, m, d := dp.Index.Date()
+		py, pm, pd := s.Last.Date()
+		if y != py || m != pm || d != pd {
+			s.PriceVolume, s.TotalVolume = 0, 0
+		}
+	}
+	s.Last = dp.Index
+	typical := (dp.Columns["high"] + dp.Columns["low"] + dp.Columns["close"]) / 3
+	volume := dp.Columns["volume"]
+	if math.IsNaN(typical) || math.IsNaN(volume) {
+		return map[string]float64{"vwap": math.NaN()}, nil
+	}
+	s.PriceVolume += typical * volume
+	s.TotalVolume += volume
+	if s.TotalVolume == 0 {
+		return map[string]float64{"vwap": math.NaN()}, nil
+	}
+	return map[string]float64{"vwap": s.PriceVolume / s.TotalVolume}, nil
+}
+
+//smoother is the incremental form of smooth
+type smoother struct {
+	Period int     `json:"period"`
+	Alpha  float64 `json:"alpha"`
+	Count  int     `json:"count"`
+	Sum    float64 `json:"sum"`
+	State  float64 `json:"state"`
+}
+
+func newSmoother(period int, alpha float64) smoother {
+	return smoother{Period: period, Alpha: alpha}
+}
+
+func (s *smoother) update(v float64) float64 {
+	if math.IsNaN(v) {
+		return math.NaN()
+	}
+	s.Count++
+	switch {
+	case s.Count < s.Period:
+		s.Sum += v
+		return math.NaN()
+	case s.Count == s.Period:
+		s.State = (s.Sum + v) / float64(s.Period)
+	default:
+		s.State = s.Alpha*v + (1-s.Alpha)*s.State
+	}
+	return s.State
+}
+
+//window holds the last Size values, NaN included
+type window struct {
+	Size   int       `json:"size"`
+	Values nanFloats `json:"values"`
+}
+
+func newWindow(size int) window {
+	return window{Size: size, Values: make(nanFloats, 0, size)}
+}
+
+func (w *window) push(v float64) {
+	w.Values = append(w.Values, v)
+	if len(w.Values) > w.Size {
+		w.Values = w.Values[len(w.Values)-w.Size:]
+	}
+}
+
+func (w *window) full() bool {
+	return len(w.Values) == w.Size
+}
+
+//mean of a full window, NaN if not full or any value is NaN
+func (w *window) mean() float64 {
+	if !w.full() {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, v := range w.Values {
+		if math.IsNaN(v) {
+			return math.NaN()
+		}
+		sum += v
+	}
+	return sum / float64(w.Size)
+}
+
+func (w *window) extreme(fn func(float64, float64) float64) float64 {
+	e := w.Values[len(w.Values)-1]
+	for _, v := range w.Values[:len(w.Values)-1] {
+		e = fn(e, v)
+	}
+	return e
+}
+
+//previous remembers the last value of a column, Seen is false before the first bar
+type previous struct {
+	Seen  bool     `json:"seen"`
+	Value nanFloat `json:"value"`
+}
+
+func (p *previous) set(v float64) {
+	p.Seen = true
+	p.Value = nanFloat(v)
+}
+
+//trueRange of the bar against the previous close, which is then updated
+func (p *previous) trueRange(dp timeseries.DataPoint) float64 {
+	high, low := dp.Columns["high"], dp.Columns["low"]
+	tr := math.NaN()
+	if p.Seen {
+		last := float64(p.Value)
+		tr = math.Max(high-low, math.Max(math.Abs(high-last), math.Abs(low-last)))
+	}
+	p.set(dp.Columns["close"])
+	return tr
+}
+
+//nanFloat marshals NaN as json null and null back to NaN
+type nanFloat float64
+
+func (f nanFloat) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(float64(f))
+}
+
+func (f *nanFloat) UnmarshalJSON(data []byte) error {
+	var v *float64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v == nil {
+		*f = nanFloat(math.NaN())
+		return nil
+	}
+	*f = nanFloat(*v)
+	return nil
+}
+
+//nanFloats marshals NaN as json null and null back to NaN
+type nanFloats []float64
+
+func (a nanFloats) MarshalJSON() ([]byte, error) {
+	values := make([]nanFloat, len(a))
+	for i, v := range a {
+		values[i] = nanFloat(v)
+	}
+	return json.Marshal(values)
+}
+
+func (a *nanFloats) UnmarshalJSON(data []byte) error {
+	var values []nanFloat
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*a = make(nanFloats, len(values))
+	for i, v := range values {
+		(*a)[i] = float64(v)
+	}
+	return nil
+}
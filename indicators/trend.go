@@ -0,0 +1,135 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+
+	timeseries "github.com/leedstyh/timeseries-go"
+	"github.com/leedstyh/timeseries-go/internal/series"
+)
+
+//SMA appends the simple moving average of column (default close) as "sma_<period>"
+//the first period-1 rows are NaN
+func SMA(ts timeseries.TimeSeries, period int, column ...string) (timeseries.TimeSeries, error) {
+	col := sourceColumn(column)
+	if err := series.Require(ts, "indicator", col); err != nil {
+		return ts, err
+	}
+	if err := requirePeriods(period); err != nil {
+		return ts, err
+	}
+	return series.With(ts, map[string][]float64{
+		fmt.Sprintf("sma_%d", period): sma(ts.Columns[col], period),
+	}), nil
+}
+
+//EMA appends the exponential moving average of column (default close) as "ema_<period>"
+//alpha is 2/(period+1), seeded with the simple average of the first period values so the first period-1 rows are NaN
+func EMA(ts timeseries.TimeSeries, period int, column ...string) (timeseries.TimeSeries, error) {
+	col := sourceColumn(column)
+	if err := series.Require(ts, "indicator", col); err != nil {
+		return ts, err
+	}
+	if err := requirePeriods(period); err != nil {
+		return ts, err
+	}
+	return series.With(ts, map[string][]float64{
+		fmt.Sprintf("ema_%d", period): ema(ts.Columns[col], period),
+	}), nil
+}
+
+//WMA appends the linearly weighted moving average of column (default close) as "wma_<period>"
+//the first period-1 rows are NaN
+func WMA(ts timeseries.TimeSeries, period int, column ...string) (timeseries.TimeSeries, error) {
+	col := sourceColumn(column)
+	if err := series.Require(ts, "indicator", col); err != nil {
+		return ts, err
+	}
+	if err := requirePeriods(period); err != nil {
+		return ts, err
+	}
+	return series.With(ts, map[string][]float64{
+		fmt.Sprintf("wma_%d", period): wma(ts.Columns[col], period),
+	}), nil
+}
+
+//MACD appends "macd" (EMA fast - EMA slow), "macd_signal" (EMA signal of macd) and "macd_hist" (macd - signal)
+//of column (default close). macd is NaN for the first slow-1 rows, signal and hist for slow+signal-2 rows
+func MACD(ts timeseries.TimeSeries, fast, slow, signal int, column ...string) (timeseries.TimeSeries, error) {
+	col := sourceColumn(column)
+	if err := series.Require(ts, "indicator", col); err != nil {
+		return ts, err
+	}
+	if err := requirePeriods(fast, slow, signal); err != nil {
+		return ts, err
+	}
+	if fast >= slow {
+		return ts, fmt.Errorf("indicator failed: macd fast period %d must be less than slow period %d", fast, slow)
+	}
+	fastEMA := ema(ts.Columns[col], fast)
+	slowEMA := ema(ts.Columns[col], slow)
+	macd := make([]float64, ts.Length())
+	for i := range macd {
+		macd[i] = fastEMA[i] - slowEMA[i]
+	}
+	signalLine := ema(macd, signal)
+	hist := make([]float64, ts.Length())
+	for i := range hist {
+		hist[i] = macd[i] - signalLine[i]
+	}
+	return series.With(ts, map[string][]float64{
+		"macd":        macd,
+		"macd_signal": signalLine,
+		"macd_hist":   hist,
+	}), nil
+}
+
+//ADX appends the average directional index "adx_<period>" with "plus_di_<period>" and "minus_di_<period>"
+//using Wilder's smoothing. DI lines are NaN for the first period rows, adx for the first 2*period-1 rows
+func ADX(ts timeseries.TimeSeries, period int) (timeseries.TimeSeries, error) {
+	if err := series.Require(ts, "indicator", "high", "low", "close"); err != nil {
+		return ts, err
+	}
+	if err := requirePeriods(period); err != nil {
+		return ts, err
+	}
+	high, low := ts.Columns["high"], ts.Columns["low"]
+	plusDM := series.NaN(ts.Length())
+	minusDM := series.NaN(ts.Length())
+	for i := 1; i < ts.Length(); i++ {
+		up, down := high[i]-high[i-1], low[i-1]-low[i]
+		plusDM[i], minusDM[i] = 0, 0
+		if up > down && up > 0 {
+			plusDM[i] = up
+		}
+		if down > up && down > 0 {
+			minusDM[i] = down
+		}
+		if math.IsNaN(up) || math.IsNaN(down) {
+			plusDM[i], minusDM[i] = math.NaN(), math.NaN()
+		}
+	}
+	tr := wilder(trueRange(high, low, ts.Columns["close"]), period)
+	smoothPlus := wilder(plusDM, period)
+	smoothMinus := wilder(minusDM, period)
+	plusDI := series.NaN(ts.Length())
+	minusDI := series.NaN(ts.Length())
+	dx := series.NaN(ts.Length())
+	for i := range dx {
+		if tr[i] == 0 {
+			continue
+		}
+		plusDI[i] = 100 * smoothPlus[i] / tr[i]
+		minusDI[i] = 100 * smoothMinus[i] / tr[i]
+		if plusDI[i]+minusDI[i] == 0 {
+			dx[i] = 0
+		} else {
+			dx[i] = 100 * math.Abs(plusDI[i]-minusDI[i]) / (plusDI[i] + minusDI[i])
+		}
+	}
+	return series.With(ts, map[string][]float64{
+		fmt.Sprintf("adx_%d", period):      wilder(dx, period),
+		fmt.Sprintf("plus_di_%d", period):  plusDI,
+		fmt.Sprintf("minus_di_%d", period): minusDI,
+	}), nil
+}
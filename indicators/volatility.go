@@ -0,0 +1,220 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	timeseries "github.com/leedstyh/timeseries-go"
+	"github.com/leedstyh/timeseries-go/internal/series"
+)
+
+//BollingerBands appends "bb_middle" (simple average of column, default close) and "bb_upper", "bb_lower"
+//at k population standard deviations around it. the first period-1 rows are NaN
+func BollingerBands(ts timeseries.TimeSeries, period int, k float64, column ...string) (timeseries.TimeSeries, error) {
+	col := sourceColumn(column)
+	if err := series.Require(ts, "indicator", col); err != nil {
+		return ts, err
+	}
+	if err := requirePeriods(period); err != nil {
+		return ts, err
+	}
+	values := ts.Columns[col]
+	middle := sma(values, period)
+	upper := series.NaN(len(values))
+	lower := series.NaN(len(values))
+	for i := period - 1; i < len(values); i++ {
+		if math.IsNaN(middle[i]) {
+			continue
+		}
+		variance := 0.0
+		for _, v := range values[i-period+1 : i+1] {
+			variance += (v - middle[i]) * (v - middle[i])
+		}
+		std := math.Sqrt(variance / float64(period))
+		upper[i] = middle[i] + k*std
+		lower[i] = middle[i] - k*std
+	}
+	return series.With(ts, map[string][]float64{
+		"bb_middle": middle,
+		"bb_upper":  upper,
+		"bb_lower":  lower,
+	}), nil
+}
+
+//ATR appends the average true range as "atr_<period>" using Wilder's smoothing
+//true range needs the previous close, so the first period rows are NaN
+func ATR(ts timeseries.TimeSeries, period int) (timeseries.TimeSeries, error) {
+	if err := series.Require(ts, "indicator", "high", "low", "close"); err != nil {
+		return ts, err
+	}
+	if err := requirePeriods(period); err != nil {
+		return ts, err
+	}
+	tr := trueRange(ts.Columns["high"], ts.Columns["low"], ts.Columns["close"])
+	return series.With(ts, map[string][]float64{
+		fmt.Sprintf("atr_%d", period): wilder(tr, period),
+	}), nil
+}
+
+//Parkinson appends "parkinson_<period>", the annualized volatility estimated from the high-low range
+//of each bar over a rolling window, it needs only high and low. annualization is the count of bars per year,
+//inferred if not given
+func Parkinson(ts timeseries.TimeSeries, period int, annualization ...float64) (timeseries.TimeSeries, error) {
+	return rangeVolatility(ts, "parkinson", period, annualization, []string{"high", "low"}, func(bar []float64) float64 {
+		hl := math.Log(bar[0] / bar[1])
+		return hl * hl / (4 * math.Ln2)
+	})
+}
+
+//GarmanKlass appends "garman_klass_<period>", the annualized volatility estimated from the open,
+//high, low and close of each bar over a rolling window. it assumes no drift and no overnight gaps
+func GarmanKlass(ts timeseries.TimeSeries, period int, annualization ...float64) (timeseries.TimeSeries, error) {
+	return rangeVolatility(ts, "garman_klass", period, annualization, ohlc, func(bar []float64) float64 {
+		hl, co := math.Log(bar[1]/bar[2]), math.Log(bar[3]/bar[0])
+		return 0.5*hl*hl - (2*math.Ln2-1)*co*co
+	})
+}
+
+//RogersSatchell appends "rogers_satchell_<period>", the annualized volatility over a rolling window
+//which stays unbiased when prices drift
+func RogersSatchell(ts timeseries.TimeSeries, period int, annualization ...float64) (timeseries.TimeSeries, error) {
+	return rangeVolatility(ts, "rogers_satchell", period, annualization, ohlc, func(bar []float64) float64 {
+		return rogersSatchell(bar[0], bar[1], bar[2], bar[3])
+	})
+}
+
+//YangZhang appends "yang_zhang_<period>", the annualized volatility combining the overnight (close to open),
+//open to close and Rogers-Satchell variances over a rolling window, robust to both drift and gaps.
+//it needs the previous close, so the first period rows are NaN. period must be at least 2
+func YangZhang(ts timeseries.TimeSeries, period int, annualization ...float64) (timeseries.TimeSeries, error) {
+	if err := series.Require(ts, "indicator", "open", "high", "low", "close"); err != nil {
+		return ts, err
+	}
+	if period < 2 {
+		return ts, fmt.Errorf("indicator failed: yang zhang period must be at least 2 not %d", period)
+	}
+	factor, err := periodsPerYear(ts, annualization)
+	if err != nil {
+		return ts, err
+	}
+	o, h, l, c := ts.Columns["open"], ts.Columns["high"], ts.Columns["low"], ts.Columns["close"]
+	overnight, intraday, rs := series.NaN(len(c)), series.NaN(len(c)), series.NaN(len(c))
+	for i := range c {
+		intraday[i] = math.Log(c[i] / o[i])
+		rs[i] = rogersSatchell(o[i], h[i], l[i], c[i])
+		if i > 0 {
+			overnight[i] = math.Log(o[i] / c[i-1])
+		}
+	}
+	n := float64(period)
+	k := 0.34 / (1.34 + (n+1)/(n-1))
+	rsMean := sma(rs, period)
+	out := series.NaN(len(c))
+	for i := period; i < len(c); i++ {
+		variance := sampleVariance(overnight[i-period+1:i+1]) + k*sampleVariance(intraday[i-period+1:i+1]) + (1-k)*rsMean[i]
+		out[i] = math.Sqrt(factor * variance)
+	}
+	return series.With(ts, map[string][]float64{
+		fmt.Sprintf("yang_zhang_%d", period): out,
+	}), nil
+}
+
+//rogersSatchell is the variance estimate of a single bar
+func rogersSatchell(o, h, l, c float64) float64 {
+	return math.Log(h/c)*math.Log(h/o) + math.Log(l/c)*math.Log(l/o)
+}
+
+//ohlc are the columns of the estimators that need the whole bar
+var ohlc = []string{"open", "high", "low", "close"}
+
+//rangeVolatility annualizes the rolling mean of a per bar variance estimate,
+//estimate gets the values of columns of a bar in their order
+func rangeVolatility(ts timeseries.TimeSeries, name string, period int, annualization []float64, columns []string, estimate func(bar []float64) float64) (timeseries.TimeSeries, error) {
+	if err := series.Require(ts, "indicator", columns...); err != nil {
+		return ts, err
+	}
+	if err := requirePeriods(period); err != nil {
+		return ts, err
+	}
+	factor, err := periodsPerYear(ts, annualization)
+	if err != nil {
+		return ts, err
+	}
+	variances := make([]float64, ts.Length())
+	bar := make([]float64, len(columns))
+	for i := range variances {
+		for j, col := range columns {
+			bar[j] = ts.Columns[col][i]
+		}
+		variances[i] = estimate(bar)
+	}
+	out := sma(variances, period)
+	for i, v := range out {
+		out[i] = math.Sqrt(factor * v)
+	}
+	return series.With(ts, map[string][]float64{
+		fmt.Sprintf("%s_%d", name, period): out,
+	}), nil
+}
+
+//sampleVariance of values, NaN if any value is NaN
+func sampleVariance(values []float64) float64 {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return sum / float64(len(values)-1)
+}
+
+//periodsPerYear is the annualization factor, the one given or inferred from the median spacing of the index
+//so a gap such as a weekend or a missing bar does not skew it. intraday bars count 252 trading days of as many bars as the median day holds, daily bars 252 days
+//and longer bars the count of intervals in 365.25 days, for ex:- 52 for weekly bars
+func periodsPerYear(ts timeseries.TimeSeries, annualization []float64) (float64, error) {
+	if annualization != nil {
+		if annualization[0] <= 0 {
+			return 0, fmt.Errorf("indicator failed: annualization must be positive not %v", annualization[0])
+		}
+		return annualization[0], nil
+	}
+	if ts.Length() < 2 {
+		return 0, fmt.Errorf("indicator failed: need min 2 rows to infer annualization")
+	}
+	interval := medianSpacing(ts.Index)
+	day := 24 * time.Hour
+	switch {
+	case interval <= 0:
+		return 0, fmt.Errorf("indicator failed: cannot infer annualization from the median spacing %v", interval)
+	case interval < day:
+		perDay := make(map[string]int)
+		for _, t := range ts.Index {
+			perDay[t.Format("2006-01-02")]++
+		}
+		counts := make([]int, 0, len(perDay))
+		for _, n := range perDay {
+			counts = append(counts, n)
+		}
+		sort.Ints(counts)
+		return 252 * float64(counts[len(counts)/2]), nil
+	case interval < 7*day:
+		return 252, nil
+	default:
+		return 365.25 * float64(day) / float64(interval), nil
+	}
+}
+
+//medianSpacing is the median gap between consecutive times
+func medianSpacing(index []time.Time) time.Duration {
+	gaps := make([]time.Duration, len(index)-1)
+	for i := range gaps {
+		gaps[i] = index[i+1].Sub(index[i])
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return gaps[len(gaps)/2]
+}
@@ -0,0 +1,78 @@
+package indicators
+
+import (
+	"math"
+
+	timeseries "github.com/leedstyh/timeseries-go"
+	"github.com/leedstyh/timeseries-go/internal/series"
+)
+
+//OBV appends on balance volume as "obv", starting at 0 on the first row.
+//volume is added on up closes and subtracted on down closes, rows with NaN close or volume are NaN
+func OBV(ts timeseries.TimeSeries) (timeseries.TimeSeries, error) {
+	if err := series.Require(ts, "indicator", "close", "volume"); err != nil {
+		return ts, err
+	}
+	closes, volume := ts.Columns["close"], ts.Columns["volume"]
+	obv := series.NaN(ts.Length())
+	running := 0.0
+	previous := math.NaN()
+	for i := range obv {
+		if math.IsNaN(closes[i]) || math.IsNaN(volume[i]) {
+			continue
+		}
+		if !math.IsNaN(previous) {
+			running += obvStep(previous, closes[i], volume[i])
+		}
+		previous = closes[i]
+		obv[i] = running
+	}
+	return series.With(ts, map[string][]float64{
+		"obv": obv,
+	}), nil
+}
+
+//obvStep is the signed volume of a price compared to the previous close
+func obvStep(previous, price, volume float64) float64 {
+	switch {
+	case price > previous:
+		return volume
+	case price < previous:
+		return -volume
+	}
+	return 0
+}
+
+//VWAP appends the volume weighted average of the typical price (high+low+close)/3 as "vwap"
+//it accumulates from the first row, or from the first row of every calendar date if daily is set.
+//rows with NaN inputs are NaN and not accumulated, rows before any volume traded are NaN
+func VWAP(ts timeseries.TimeSeries, daily ...bool) (timeseries.TimeSeries, error) {
+	if err := series.Require(ts, "indicator", "high", "low", "close", "volume"); err != nil {
+		return ts, err
+	}
+	resetDaily := daily != nil && daily[0]
+	high, low, closes, volume := ts.Columns["high"], ts.Columns["low"], ts.Columns["close"], ts.Columns["volume"]
+	vwap := series.NaN(ts.Length())
+	var priceVolume, totalVolume float64
+	for i, t := range ts.Index {
+		if resetDaily && i > 0 {
+			y, m, d := t.Date()
+			py, pm, pd := ts.Index[i-1].Date()
+			if y != py || m != pm || d != pd {
+				priceVolume, totalVolume = 0, 0
+			}
+		}
+		typical := (high[i] + low[i] + closes[i]) / 3
+		if math.IsNaN(typical) || math.IsNaN(volume[i]) {
+			continue
+		}
+		priceVolume += typical * volume[i]
+		totalVolume += volume[i]
+		if totalVolume != 0 {
+			vwap[i] = priceVolume / totalVolume
+		}
+	}
+	return series.With(ts, map[string][]float64{
+		"vwap": vwap,
+	}), nil
+}
@@ -0,0 +1,45 @@
+//Package series holds the column helpers shared by the indicators and options packages
+package series
+
+import (
+	"fmt"
+	"math"
+
+	timeseries "github.com/leedstyh/timeseries-go"
+)
+
+//Require errors if any of columns is missing in ts, name prefixes the error, for ex:- "indicator failed: ..."
+func Require(ts timeseries.TimeSeries, name string, columns ...string) error {
+	for _, col := range columns {
+		if _, ok := ts.Columns[col]; !ok {
+			return fmt.Errorf("%s failed: no such column %s", name, col)
+		}
+	}
+	return nil
+}
+
+//With returns a copy of ts with columns added, ts itself is not modified
+func With(ts timeseries.TimeSeries, columns map[string][]float64) timeseries.TimeSeries {
+	out := timeseries.NewTimeSeries()
+	out.Index = ts.Index
+	out.MaxSize = ts.MaxSize
+	for k, v := range ts.Meta {
+		out.Meta[k] = v
+	}
+	for k, v := range ts.Columns {
+		out.Columns[k] = v
+	}
+	for k, v := range columns {
+		out.Columns[k] = v
+	}
+	return out
+}
+
+//NaN returns an array of n NaN
+func NaN(n int) []float64 {
+	arr := make([]float64, n)
+	for i := range arr {
+		arr[i] = math.NaN()
+	}
+	return arr
+}
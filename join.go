@@ -0,0 +1,216 @@
+package timeseries
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+//Join puts two `TimeSeries` side by side aligned on Index, both indexes must be sorted
+//how can be "inner" (timestamps in both), "outer" (timestamps in either) or "left" (timestamps of ts)
+//missing values are NaN. clashing column names get suffixes, default "_left" and "_right".
+//Meta of both sides is kept, clashing keys with different values get the same suffixes
+func (ts TimeSeries) Join(other TimeSeries, how string, suffixes ...string) (TimeSeries, error) {
+	leftSuffix, rightSuffix, err := joinSuffixes(suffixes)
+	if err != nil {
+		return ts, fmt.Errorf("join failed: %v", err)
+	}
+	if how != "inner" && how != "outer" && how != "left" {
+		return ts, fmt.Errorf("join failed: how must be inner, outer or left not %s", how)
+	}
+	leftNames, rightNames, err := joinColumnNames(ts, other, leftSuffix, rightSuffix)
+	if err != nil {
+		return ts, err
+	}
+	joined := NewTimeSeries()
+	joined.MaxSize = ts.MaxSize
+	joined.Meta = joinMeta(ts.Meta, other.Meta, leftSuffix, rightSuffix)
+	appendRow := func(i, j int) {
+		if i >= 0 {
+			joined.Index = append(joined.Index, ts.Index[i])
+		} else {
+			joined.Index = append(joined.Index, other.Index[j])
+		}
+		for col, name := range leftNames {
+			value := math.NaN()
+			if i >= 0 {
+				value = ts.Columns[col][i]
+			}
+			joined.Columns[name] = append(joined.Columns[name], value)
+		}
+		for col, name := range rightNames {
+			value := math.NaN()
+			if j >= 0 {
+				value = other.Columns[col][j]
+			}
+			joined.Columns[name] = append(joined.Columns[name], value)
+		}
+	}
+	i, j := 0, 0
+	for i < ts.Length() || j < other.Length() {
+		switch {
+		case j == other.Length() || (i < ts.Length() && ts.Index[i].Before(other.Index[j])):
+			if how != "inner" {
+				appendRow(i, -1)
+			}
+			i++
+		case i == ts.Length() || other.Index[j].Before(ts.Index[i]):
+			if how == "outer" {
+				appendRow(-1, j)
+			}
+			j++
+		default:
+			appendRow(i, j)
+			i++
+			j++
+		}
+	}
+	for _, name := range leftNames {
+		if _, ok := joined.Columns[name]; !ok {
+			joined.Columns[name] = make([]float64, 0)
+		}
+	}
+	for _, name := range rightNames {
+		if _, ok := joined.Columns[name]; !ok {
+			joined.Columns[name] = make([]float64, 0)
+		}
+	}
+	return joined, nil
+}
+
+//joinSuffixes are the two suffixes provided, default "_left" and "_right"
+func joinSuffixes(suffixes []string) (string, string, error) {
+	if suffixes == nil {
+		return "_left", "_right", nil
+	}
+	if len(suffixes) != 2 {
+		return "", "", fmt.Errorf("need two suffixes, found %d", len(suffixes))
+	}
+	return suffixes[0], suffixes[1], nil
+}
+
+//joinColumnNames maps the columns of both sides to their names in the joined series
+func joinColumnNames(left, right TimeSeries, leftSuffix, rightSuffix string) (map[string]string, map[string]string, error) {
+	leftNames := make(map[string]string)
+	rightNames := make(map[string]string)
+	for col := range left.Columns {
+		leftNames[col] = col
+		if _, ok := right.Columns[col]; ok {
+			leftNames[col] = col + leftSuffix
+		}
+	}
+	for col := range right.Columns {
+		rightNames[col] = col
+		if _, ok := left.Columns[col]; ok {
+			rightNames[col] = col + rightSuffix
+		}
+	}
+	seen := make(map[string]bool)
+	for _, names := range []map[string]string{leftNames, rightNames} {
+		for _, name := range names {
+			if seen[name] {
+				return nil, nil, fmt.Errorf("join failed: column `%s` clashes even after suffixing", name)
+			}
+			seen[name] = true
+		}
+	}
+	return leftNames, rightNames, nil
+}
+
+//joinMeta merges Meta of both sides, clashing keys with different values are suffixed
+func joinMeta(left, right map[string]string, leftSuffix, rightSuffix string) map[string]string {
+	meta := make(map[string]string)
+	for k, v := range left {
+		if rv, ok := right[k]; ok && rv != v {
+			meta[k+leftSuffix] = v
+			continue
+		}
+		meta[k] = v
+	}
+	for k, v := range right {
+		if lv, ok := left[k]; ok && lv != v {
+			meta[k+rightSuffix] = v
+			continue
+		}
+		meta[k] = v
+	}
+	return meta
+}
+
+//AsOfOptions configures AsOfJoin, the zero value matches rows at any distance
+type AsOfOptions struct {
+	//Tolerance is the max distance of a match when HasTolerance is set, 0 then matches equal timestamps only
+	Tolerance    time.Duration
+	HasTolerance bool
+}
+
+//AsOfJoin joins each row of ts with the closest row of other instead of an exact timestamp match.
+//both indexes must be sorted. direction can be "backward" (last row at or before),
+//"forward" (first row at or after) or "nearest". rows without a match within options get NaN,
+//clashing column names and Meta get suffixes as in Join, default "_left" and "_right"
+func (ts TimeSeries) AsOfJoin(other TimeSeries, direction string, options AsOfOptions, suffixes ...string) (TimeSeries, error) {
+	leftSuffix, rightSuffix, err := joinSuffixes(suffixes)
+	if err != nil {
+		return ts, fmt.Errorf("asof join failed: %v", err)
+	}
+	if direction != "backward" && direction != "forward" && direction != "nearest" {
+		return ts, fmt.Errorf("asof join failed: direction must be backward, forward or nearest not %s", direction)
+	}
+	if options.Tolerance < 0 {
+		return ts, fmt.Errorf("asof join failed: negative tolerance %v", options.Tolerance)
+	}
+	leftNames, rightNames, err := joinColumnNames(ts, other, leftSuffix, rightSuffix)
+	if err != nil {
+		return ts, err
+	}
+	joined := NewTimeSeries()
+	joined.Index = append(joined.Index, ts.Index...)
+	joined.MaxSize = ts.MaxSize
+	joined.Meta = joinMeta(ts.Meta, other.Meta, leftSuffix, rightSuffix)
+	for col, name := range leftNames {
+		joined.Columns[name] = append([]float64{}, ts.Columns[col]...)
+	}
+	for _, name := range rightNames {
+		joined.Columns[name] = make([]float64, 0, ts.Length())
+	}
+	var before, after int //count of rows in other at or before, and strictly before t
+	for _, t := range ts.Index {
+		for before < other.Length() && !other.Index[before].After(t) {
+			before++
+		}
+		for after < other.Length() && other.Index[after].Before(t) {
+			after++
+		}
+		match := -1
+		switch direction {
+		case "backward":
+			match = before - 1
+		case "forward":
+			if after < other.Length() {
+				match = after
+			}
+		case "nearest":
+			match = before - 1
+			if after < other.Length() && (match < 0 || other.Index[after].Sub(t) < t.Sub(other.Index[match])) {
+				match = after
+			}
+		}
+		if match >= 0 && options.HasTolerance {
+			distance := t.Sub(other.Index[match])
+			if distance < 0 {
+				distance = -distance
+			}
+			if distance > options.Tolerance {
+				match = -1
+			}
+		}
+		for col, name := range rightNames {
+			value := math.NaN()
+			if match >= 0 {
+				value = other.Columns[col][match]
+			}
+			joined.Columns[name] = append(joined.Columns[name], value)
+		}
+	}
+	return joined, nil
+}
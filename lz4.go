@@ -0,0 +1,101 @@
+package timeseries
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+//lz4FrameDecode decompresses an lz4 frame, the format arrow uses for its LZ4_FRAME buffer compression.
+//checksums are skipped, not verified
+func lz4FrameDecode(src []byte) ([]byte, error) {
+	if len(src) < 7 || binary.LittleEndian.Uint32(src) != 0x184d2204 {
+		return nil, fmt.Errorf("lz4 decode failed: not an lz4 frame")
+	}
+	flags := src[4]
+	if flags>>6 != 1 {
+		return nil, fmt.Errorf("lz4 decode failed: unsupported frame version %d", flags>>6)
+	}
+	i := 6
+	if flags&0x08 != 0 { //content size
+		i += 8
+	}
+	if flags&0x01 != 0 { //dictionary id
+		i += 4
+	}
+	i++ //header checksum
+	var dst []byte
+	for {
+		if i+4 > len(src) {
+			return nil, fmt.Errorf("lz4 decode failed: truncated frame")
+		}
+		size := binary.LittleEndian.Uint32(src[i:])
+		i += 4
+		if size == 0 {
+			return dst, nil
+		}
+		n := int(size & 0x7fffffff)
+		if n > len(src)-i {
+			return nil, fmt.Errorf("lz4 decode failed: truncated block")
+		}
+		var err error
+		if size&0x80000000 != 0 {
+			dst = append(dst, src[i:i+n]...)
+		} else if dst, err = lz4BlockDecode(src[i:i+n], dst); err != nil {
+			return nil, err
+		}
+		i += n
+		if flags&0x10 != 0 { //block checksum
+			i += 4
+		}
+	}
+}
+
+//lz4BlockDecode appends the decompressed block to dst, matches may reach back into earlier blocks of dst
+func lz4BlockDecode(src, dst []byte) ([]byte, error) {
+	length := func(i, n int) (int, int, error) {
+		if n != 15 {
+			return i, n, nil
+		}
+		for {
+			if i >= len(src) {
+				return i, 0, fmt.Errorf("lz4 decode failed: truncated length")
+			}
+			n += int(src[i])
+			i++
+			if src[i-1] != 255 {
+				return i, n, nil
+			}
+		}
+	}
+	for i := 0; i < len(src); {
+		token := src[i]
+		var literals, size int
+		var err error
+		if i, literals, err = length(i+1, int(token>>4)); err != nil {
+			return nil, err
+		}
+		if literals > len(src)-i {
+			return nil, fmt.Errorf("lz4 decode failed: truncated literals")
+		}
+		dst = append(dst, src[i:i+literals]...)
+		i += literals
+		if i == len(src) { //the last sequence has no match
+			return dst, nil
+		}
+		if i+2 > len(src) {
+			return nil, fmt.Errorf("lz4 decode failed: truncated offset")
+		}
+		offset := int(binary.LittleEndian.Uint16(src[i:]))
+		if i, size, err = length(i+2, int(token&0x0f)); err != nil {
+			return nil, err
+		}
+		if offset == 0 || offset > len(dst) {
+			return nil, fmt.Errorf("lz4 decode failed: invalid match offset %d", offset)
+		}
+		start := len(dst) - offset
+		for j := 0; j < size+4; j++ {
+			dst = append(dst, dst[start+j])
+		}
+	}
+	return dst, nil
+}
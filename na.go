@@ -0,0 +1,168 @@
+package timeseries
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+)
+
+//NA is the missing value marker used throughout the package
+//every loader, aggregator and writer treats NaN as a missing value
+var NA = math.NaN()
+
+//IsNA returns a truth table with true where any of the columns provided is NaN
+//if no columns provided, all columns are checked, columns not in the timeseries are skipped. plugs into FilterByTruthTable
+func (ts TimeSeries) IsNA(columns ...string) []bool {
+	if columns == nil {
+		columns = ts.ListColumns()
+	}
+	present := make([][]float64, 0, len(columns))
+	for _, col := range columns {
+		if values, ok := ts.Columns[col]; ok {
+			present = append(present, values)
+		}
+	}
+	mask := make([]bool, ts.Length())
+	for i := range ts.Index {
+		for _, values := range present {
+			if i < len(values) && math.IsNaN(values[i]) {
+				mask[i] = true
+				break
+			}
+		}
+	}
+	return mask
+}
+
+//NotNA is the inverse of IsNA, true where none of the columns provided is NaN
+func (ts TimeSeries) NotNA(columns ...string) []bool {
+	mask := ts.IsNA(columns...)
+	for i := range mask {
+		mask[i] = !mask[i]
+	}
+	return mask
+}
+
+//DropNA removes missing values. axis can be "rows" or "columns", default "rows"
+//rows: every row with a NaN in any column is dropped
+//columns: every column holding a NaN is dropped
+func (ts TimeSeries) DropNA(axis ...string) TimeSeries {
+	if axis != nil && axis[0] == "columns" {
+		dropped := NewTimeSeries()
+		dropped.Index = append(dropped.Index, ts.Index...)
+		dropped.MaxSize = ts.MaxSize
+		for k, v := range ts.Meta {
+			dropped.Meta[k] = v
+		}
+		for col, values := range ts.Columns {
+			if countNA(values) == 0 {
+				dropped.Columns[col] = append([]float64{}, values...)
+			}
+		}
+		return dropped
+	}
+	dropped, _ := ts.FilterByTruthTable(ts.NotNA(), true)
+	dropped.MaxSize = ts.MaxSize
+	for k, v := range ts.Meta {
+		dropped.Meta[k] = v
+	}
+	return dropped
+}
+
+//countNA counts the NaN values in arr
+func countNA(arr []float64) int {
+	count := 0
+	for _, v := range arr {
+		if math.IsNaN(v) {
+			count++
+		}
+	}
+	return count
+}
+
+//dropNA returns the non NaN values of arr
+func dropNA(arr []float64) []float64 {
+	clean := make([]float64, 0, len(arr))
+	for _, v := range arr {
+		if !math.IsNaN(v) {
+			clean = append(clean, v)
+		}
+	}
+	return clean
+}
+
+//nanArray is a float array which marshals NaN as json null and null back to NaN
+type nanArray []float64
+
+//MarshalJSON writes NaN as null
+func (a nanArray) MarshalJSON() ([]byte, error) {
+	if a == nil {
+		return []byte("null"), nil
+	}
+	buf := []byte{'['}
+	for i, v := range a {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			buf = append(buf, "null"...)
+		} else {
+			buf = strconv.AppendFloat(buf, v, 'g', -1, 64)
+		}
+	}
+	return append(buf, ']'), nil
+}
+
+//UnmarshalJSON reads null as NaN
+func (a *nanArray) UnmarshalJSON(data []byte) error {
+	var raw []*float64
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*a = nil
+		return nil
+	}
+	arr := make(nanArray, len(raw))
+	for i, v := range raw {
+		if v == nil {
+			arr[i] = math.NaN()
+		} else {
+			arr[i] = *v
+		}
+	}
+	*a = arr
+	return nil
+}
+
+//nanColumns is a column map which marshals NaN as json null and null back to NaN
+type nanColumns map[string][]float64
+
+//MarshalJSON writes NaN as null
+func (c nanColumns) MarshalJSON() ([]byte, error) {
+	if c == nil {
+		return []byte("null"), nil
+	}
+	columns := make(map[string]nanArray, len(c))
+	for k, v := range c {
+		columns[k] = v
+	}
+	return json.Marshal(columns)
+}
+
+//UnmarshalJSON reads null as NaN
+func (c *nanColumns) UnmarshalJSON(data []byte) error {
+	var columns map[string]nanArray
+	if err := json.Unmarshal(data, &columns); err != nil {
+		return err
+	}
+	if columns == nil {
+		*c = nil
+		return nil
+	}
+	*c = make(nanColumns, len(columns))
+	for k, v := range columns {
+		(*c)[k] = v
+	}
+	return nil
+}
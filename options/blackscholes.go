@@ -0,0 +1,130 @@
+package options
+
+import (
+	"fmt"
+	"math"
+)
+
+//Params are the Black-Scholes inputs of a European option. Years is the time to expiry in years,
+//Rate and Dividend are continuously compounded annual rates and Vol is the annualized volatility, 0.2 for 20%
+type Params struct {
+	Call     bool
+	Spot     float64
+	Strike   float64
+	Years    float64
+	Rate     float64
+	Dividend float64
+	Vol      float64
+}
+
+//Greeks are the sensitivities of an option price. Vega and Rho are per unit of volatility and rate,
+//divide by 100 for a 1% move. Theta is per year, divide by 365 for a day
+type Greeks struct {
+	Delta float64
+	Gamma float64
+	Vega  float64
+	Theta float64
+	Rho   float64
+}
+
+//d1 and d2 of the Black-Scholes formula
+func (p Params) d() (float64, float64) {
+	sqrtT := math.Sqrt(p.Years)
+	d1 := (math.Log(p.Spot/p.Strike) + (p.Rate-p.Dividend+p.Vol*p.Vol/2)*p.Years) / (p.Vol * sqrtT)
+	return d1, d1 - p.Vol*sqrtT
+}
+
+//Price is the Black-Scholes price, the intrinsic value at or after expiry
+func (p Params) Price() float64 {
+	if p.Years <= 0 {
+		return p.intrinsic()
+	}
+	d1, d2 := p.d()
+	spot, strike := p.Spot*math.Exp(-p.Dividend*p.Years), p.Strike*math.Exp(-p.Rate*p.Years)
+	if p.Call {
+		return spot*cdf(d1) - strike*cdf(d2)
+	}
+	return strike*cdf(-d2) - spot*cdf(-d1)
+}
+
+//Greeks of the option, NaN at or after expiry
+func (p Params) Greeks() Greeks {
+	if p.Years <= 0 {
+		nan := math.NaN()
+		return Greeks{nan, nan, nan, nan, nan}
+	}
+	d1, d2 := p.d()
+	sqrtT := math.Sqrt(p.Years)
+	carry, discount := math.Exp(-p.Dividend*p.Years), math.Exp(-p.Rate*p.Years)
+	g := Greeks{
+		Gamma: carry * pdf(d1) / (p.Spot * p.Vol * sqrtT),
+		Vega:  p.Spot * carry * pdf(d1) * sqrtT,
+	}
+	decay := -p.Spot * carry * pdf(d1) * p.Vol / (2 * sqrtT)
+	if p.Call {
+		g.Delta = carry * cdf(d1)
+		g.Theta = decay - p.Rate*p.Strike*discount*cdf(d2) + p.Dividend*p.Spot*carry*cdf(d1)
+		g.Rho = p.Strike * p.Years * discount * cdf(d2)
+	} else {
+		g.Delta = -carry * cdf(-d1)
+		g.Theta = decay + p.Rate*p.Strike*discount*cdf(-d2) - p.Dividend*p.Spot*carry*cdf(-d1)
+		g.Rho = -p.Strike * p.Years * discount * cdf(-d2)
+	}
+	return g
+}
+
+//intrinsic value of the option
+func (p Params) intrinsic() float64 {
+	if p.Call {
+		return math.Max(p.Spot-p.Strike, 0)
+	}
+	return math.Max(p.Strike-p.Spot, 0)
+}
+
+//ImpliedVol solves for the volatility at which the Black-Scholes price equals price, p.Vol is ignored.
+//it uses Newton's method and falls back to bisection, the result is within 1e-8 of the price.
+//prices outside the no arbitrage bounds have no solution and return an error
+func ImpliedVol(price float64, p Params) (float64, error) {
+	if p.Years <= 0 {
+		return math.NaN(), fmt.Errorf("implied vol failed: option expired")
+	}
+	spot, strike := p.Spot*math.Exp(-p.Dividend*p.Years), p.Strike*math.Exp(-p.Rate*p.Years)
+	lower, upper := math.Max(spot-strike, 0), spot
+	if !p.Call {
+		lower, upper = math.Max(strike-spot, 0), strike
+	}
+	if math.IsNaN(price) || price <= lower || price >= upper {
+		return math.NaN(), fmt.Errorf("implied vol failed: price %v outside bounds (%v, %v)", price, lower, upper)
+	}
+	const tolerance = 1e-8
+	low, high := 1e-6, 10.0
+	p.Vol = 0.2
+	for i := 0; i < 100; i++ {
+		diff := p.Price() - price
+		if math.Abs(diff) < tolerance {
+			return p.Vol, nil
+		}
+		if diff > 0 {
+			high = p.Vol
+		} else {
+			low = p.Vol
+		}
+		vega := p.Greeks().Vega
+		next := p.Vol - diff/vega
+		if vega < tolerance || next <= low || next >= high {
+			next = (low + high) / 2
+		}
+		p.Vol = next
+	}
+	return math.NaN(), fmt.Errorf("implied vol failed: no convergence for price %v", price)
+}
+
+//cdf of the standard normal distribution
+func cdf(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+//pdf of the standard normal distribution
+func pdf(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
@@ -0,0 +1,202 @@
+//Package options prices European options with Black-Scholes and analyses the quotes of option contracts.
+//the scalar model lives on Params, for ex:- Params{Call: true, Spot: 100, Strike: 105, Years: 0.25, Vol: 0.2}.Price().
+//the column-wise functions take the `TimeSeries` of one contract, as loaded with its oi and iv columns,
+//and return a copy with their results appended as named columns
+package options
+
+import (
+	"math"
+	"time"
+
+	timeseries "github.com/leedstyh/timeseries-go"
+	"github.com/leedstyh/timeseries-go/internal/series"
+)
+
+//Contract describes the option a `TimeSeries` holds the quotes of.
+//Rate and Dividend are continuously compounded annual rates used for every row
+type Contract struct {
+	Call     bool
+	Strike   float64
+	Expiry   time.Time
+	Rate     float64
+	Dividend float64
+}
+
+//Columns names the columns read, defaults Price close, Spot spot (the underlying), IV iv and OI oi.
+//IVPercent reads IV quoted in percent, like 23.5 for 23.5%
+type Columns struct {
+	Price     string
+	Spot      string
+	IV        string
+	OI        string
+	IVPercent bool
+}
+
+//columnNames fills in the defaults of the columns given
+func columnNames(columns []Columns) Columns {
+	c := Columns{Price: "close", Spot: "spot", IV: "iv", OI: "oi"}
+	if columns == nil {
+		return c
+	}
+	if columns[0].Price != "" {
+		c.Price = columns[0].Price
+	}
+	if columns[0].Spot != "" {
+		c.Spot = columns[0].Spot
+	}
+	if columns[0].IV != "" {
+		c.IV = columns[0].IV
+	}
+	if columns[0].OI != "" {
+		c.OI = columns[0].OI
+	}
+	c.IVPercent = columns[0].IVPercent
+	return c
+}
+
+//params of the contract at t, Years is the time left to expiry on a 365 day year
+func (c Contract) params(t time.Time, spot, vol float64) Params {
+	return Params{
+		Call:     c.Call,
+		Spot:     spot,
+		Strike:   c.Strike,
+		Years:    c.Expiry.Sub(t).Hours() / 24 / 365,
+		Rate:     c.Rate,
+		Dividend: c.Dividend,
+		Vol:      vol,
+	}
+}
+
+//vols reads the implied volatility column as decimals
+func (c Columns) vols(ts timeseries.TimeSeries) []float64 {
+	vols := ts.Columns[c.IV]
+	if !c.IVPercent {
+		return vols
+	}
+	scaled := make([]float64, len(vols))
+	for i, v := range vols {
+		scaled[i] = v / 100
+	}
+	return scaled
+}
+
+//TheoreticalPrice appends "bs_price", the Black-Scholes price of each row from the spot and iv columns
+func TheoreticalPrice(ts timeseries.TimeSeries, c Contract, columns ...Columns) (timeseries.TimeSeries, error) {
+	cols := columnNames(columns)
+	if err := series.Require(ts, "options", cols.Spot, cols.IV); err != nil {
+		return ts, err
+	}
+	spots, vols := ts.Columns[cols.Spot], cols.vols(ts)
+	prices := make([]float64, ts.Length())
+	for i, t := range ts.Index {
+		prices[i] = c.params(t, spots[i], vols[i]).Price()
+	}
+	return series.With(ts, map[string][]float64{"bs_price": prices}), nil
+}
+
+//ImpliedVolatility appends "implied_vol", the volatility implied by the price and spot columns as a decimal.
+//rows without a solution, like prices outside the no arbitrage bounds or expired contracts, are NaN
+func ImpliedVolatility(ts timeseries.TimeSeries, c Contract, columns ...Columns) (timeseries.TimeSeries, error) {
+	cols := columnNames(columns)
+	if err := series.Require(ts, "options", cols.Price, cols.Spot); err != nil {
+		return ts, err
+	}
+	prices, spots := ts.Columns[cols.Price], ts.Columns[cols.Spot]
+	vols := make([]float64, ts.Length())
+	for i, t := range ts.Index {
+		vols[i], _ = ImpliedVol(prices[i], c.params(t, spots[i], 0))
+	}
+	return series.With(ts, map[string][]float64{"implied_vol": vols}), nil
+}
+
+//AppendGreeks appends "delta", "gamma", "vega", "theta" and "rho" of each row from the spot and iv columns,
+//see `Greeks` for their units. to use solved volatilities run ImpliedVolatility first with Columns{IV: "implied_vol"}
+func AppendGreeks(ts timeseries.TimeSeries, c Contract, columns ...Columns) (timeseries.TimeSeries, error) {
+	cols := columnNames(columns)
+	if err := series.Require(ts, "options", cols.Spot, cols.IV); err != nil {
+		return ts, err
+	}
+	spots, vols := ts.Columns[cols.Spot], cols.vols(ts)
+	names := []string{"delta", "gamma", "vega", "theta", "rho"}
+	greeks := make(map[string][]float64)
+	for _, name := range names {
+		greeks[name] = make([]float64, ts.Length())
+	}
+	for i, t := range ts.Index {
+		g := c.params(t, spots[i], vols[i]).Greeks()
+		for j, value := range []float64{g.Delta, g.Gamma, g.Vega, g.Theta, g.Rho} {
+			greeks[names[j]][i] = value
+		}
+	}
+	return series.With(ts, greeks), nil
+}
+
+//OIChange appends "oi_change" and "oi_pct_change", the change in open interest over the previous row
+//as a count and a fraction. the first row is NaN
+func OIChange(ts timeseries.TimeSeries, columns ...Columns) (timeseries.TimeSeries, error) {
+	cols := columnNames(columns)
+	if err := series.Require(ts, "options", cols.OI); err != nil {
+		return ts, err
+	}
+	oi := ts.Columns[cols.OI]
+	change, pctChange := series.NaN(len(oi)), series.NaN(len(oi))
+	for i := 1; i < len(oi); i++ {
+		change[i] = oi[i] - oi[i-1]
+		pctChange[i] = change[i] / oi[i-1]
+	}
+	return series.With(ts, map[string][]float64{"oi_change": change, "oi_pct_change": pctChange}), nil
+}
+
+//OIBuildup appends "oi_buildup", reading the change in price and open interest over the previous row:
+//2 long buildup (price up, oi up), 1 short covering (price up, oi down),
+//-1 long unwinding (price down, oi down), -2 short buildup (price down, oi up), 0 otherwise. the first row is NaN
+func OIBuildup(ts timeseries.TimeSeries, columns ...Columns) (timeseries.TimeSeries, error) {
+	cols := columnNames(columns)
+	if err := series.Require(ts, "options", cols.Price, cols.OI); err != nil {
+		return ts, err
+	}
+	prices, oi := ts.Columns[cols.Price], ts.Columns[cols.OI]
+	buildup := series.NaN(len(oi))
+	for i := 1; i < len(oi); i++ {
+		dp, doi := prices[i]-prices[i-1], oi[i]-oi[i-1]
+		switch {
+		case math.IsNaN(dp) || math.IsNaN(doi): //stays NaN
+		case dp > 0 && doi > 0:
+			buildup[i] = 2
+		case dp > 0 && doi < 0:
+			buildup[i] = 1
+		case dp < 0 && doi < 0:
+			buildup[i] = -1
+		case dp < 0 && doi > 0:
+			buildup[i] = -2
+		default:
+			buildup[i] = 0
+		}
+	}
+	return series.With(ts, map[string][]float64{"oi_buildup": buildup}), nil
+}
+
+//PCR is the put call ratio of column, for ex:- oi or volume, over the timestamps both series share.
+//the result holds column_call, column_put and "pcr". to get the ratio over a chain pass the sums over its strikes
+func PCR(calls, puts timeseries.TimeSeries, column string) (timeseries.TimeSeries, error) {
+	if err := series.Require(calls, "options", column); err != nil {
+		return calls, err
+	}
+	if err := series.Require(puts, "options", column); err != nil {
+		return calls, err
+	}
+	callSide, putSide := timeseries.NewTimeSeries(), timeseries.NewTimeSeries()
+	callSide.Index, putSide.Index = calls.Index, puts.Index
+	callSide.Columns[column], putSide.Columns[column] = calls.Columns[column], puts.Columns[column]
+	joined, err := callSide.Join(putSide, "inner", "_call", "_put")
+	if err != nil {
+		return calls, err
+	}
+	callValues, putValues := joined.Columns[column+"_call"], joined.Columns[column+"_put"]
+	pcr := make([]float64, joined.Length())
+	for i := range pcr {
+		pcr[i] = putValues[i] / callValues[i]
+	}
+	joined.Columns["pcr"] = pcr
+	return joined, nil
+}
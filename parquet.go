@@ -0,0 +1,940 @@
+package timeseries
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//parquet physical types, encodings, codecs and page types used by the reader and writer
+const (
+	parquetBoolean = 0
+	parquetInt32   = 1
+	parquetInt64   = 2
+	parquetInt96   = 3
+	parquetFloat   = 4
+	parquetDouble  = 5
+
+	parquetPlain          = 0
+	parquetPlainDict      = 2
+	parquetRLE            = 3
+	parquetRLEDict        = 8
+	parquetUncompressed   = 0
+	parquetSnappy         = 1
+	parquetGzip           = 2
+	parquetDataPage       = 0
+	parquetDictionaryPage = 2
+	parquetDataPageV2     = 3
+
+	parquetRequired = 0
+	parquetOptional = 1
+	parquetRepeated = 2
+
+	parquetMagic         = "PAR1"
+	parquetIndexColumn   = "timestamp"
+	parquetTimezoneKey   = "timeseries.timezone"
+	parquetRowGroupSize  = 100000
+	parquetJulianEpoch   = 2440588
+	parquetMaxFooterSize = 1 << 28
+	parquetMaxRows       = 1 << 26 //per row group, the most arrow writes
+)
+
+//ParquetOptions configures WriteAsParquet
+type ParquetOptions struct {
+	//RowGroupSize is the count of rows per row group, default 100000
+	RowGroupSize int
+	//TimeUnit of the timestamp column, "ms", "us" (default) or "ns". pandas reads all of them, Spark up to "us"
+	TimeUnit string
+	//Compression of the pages, "none" (default) or "gzip"
+	Compression string
+}
+
+//ParquetReadOptions configures NewTimeSeriesFromParquet
+type ParquetReadOptions struct {
+	//Columns to load besides the index, default all numeric columns
+	Columns []string
+	//Start and End bound the rows loaded, both inclusive, zero values are unbounded.
+	//row groups whose timestamp statistics fall outside are not read at all
+	Start time.Time
+	End   time.Time
+	//Index is the timestamp column, default the first timestamp or date column
+	Index string
+}
+
+//WriteAsParquet writes the timeseries to a parquet file at path. the Index is written as the column timestamp,
+//an INT64 TIMESTAMP adjusted to UTC whose timezone is kept in the file metadata, columns are written as
+//optional DOUBLE columns with NaN as null, and Meta is written as key-value file metadata
+func (ts TimeSeries) WriteAsParquet(path string, options ...ParquetOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return ts.WriteParquet(f, options...)
+}
+
+//countingWriter tracks the offset parquet metadata points at
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+//parquetChunk is the metadata of a written column chunk
+type parquetChunk struct {
+	name         string
+	physical     int32
+	values       int64
+	offset       int64
+	uncompressed int64
+	compressed   int64
+	nulls        int64
+	min, max     []byte
+}
+
+//WriteParquet writes the timeseries to w in parquet as WriteAsParquet does
+func (ts TimeSeries) WriteParquet(w io.Writer, options ...ParquetOptions) error {
+	var opts ParquetOptions
+	if options != nil {
+		opts = options[0]
+	}
+	if opts.RowGroupSize <= 0 {
+		opts.RowGroupSize = parquetRowGroupSize
+	}
+	var unit time.Duration
+	var unitField int16
+	var converted int32
+	switch opts.TimeUnit {
+	case "ms":
+		unit, unitField, converted = time.Millisecond, 1, 9
+	case "", "us":
+		unit, unitField, converted = time.Microsecond, 2, 10
+	case "ns":
+		unit, unitField, converted = time.Nanosecond, 3, -1
+	default:
+		return fmt.Errorf("parquet write failed: time unit must be ms, us or ns not %s", opts.TimeUnit)
+	}
+	var codec int32
+	switch opts.Compression {
+	case "", "none":
+		codec = parquetUncompressed
+	case "gzip":
+		codec = parquetGzip
+	default:
+		return fmt.Errorf("parquet write failed: compression must be none or gzip not %s", opts.Compression)
+	}
+	columns := ts.ListColumns()
+	sort.Strings(columns)
+	if aInB(parquetIndexColumn, columns) {
+		return fmt.Errorf("parquet write failed: column name %s is reserved for the index", parquetIndexColumn)
+	}
+	out := &countingWriter{w: w}
+	if _, err := io.WriteString(out, parquetMagic); err != nil {
+		return err
+	}
+	var rowGroups [][]parquetChunk
+	for start := 0; start < ts.Length(); start += opts.RowGroupSize {
+		end := start + opts.RowGroupSize
+		if end > ts.Length() {
+			end = ts.Length()
+		}
+		chunks := make([]parquetChunk, 0, len(columns)+1)
+		body := make([]byte, 8*(end-start))
+		var min, max int64
+		for i, t := range ts.Index[start:end] {
+			v := t.UnixNano() / int64(unit)
+			binary.LittleEndian.PutUint64(body[8*i:], uint64(v))
+			if i == 0 || v < min {
+				min = v
+			}
+			if i == 0 || v > max {
+				max = v
+			}
+		}
+		chunk := parquetChunk{name: parquetIndexColumn, physical: parquetInt64, values: int64(end - start), min: make([]byte, 8), max: make([]byte, 8)}
+		binary.LittleEndian.PutUint64(chunk.min, uint64(min))
+		binary.LittleEndian.PutUint64(chunk.max, uint64(max))
+		if err := writeParquetPage(out, &chunk, body, codec, false); err != nil {
+			return err
+		}
+		chunks = append(chunks, chunk)
+		for _, col := range columns {
+			values := ts.Columns[col][start:end]
+			chunk := parquetChunk{name: col, physical: parquetDouble, values: int64(len(values))}
+			levels := make([]uint64, len(values))
+			body := make([]byte, 0, 8*len(values))
+			min, max := math.Inf(1), math.Inf(-1)
+			for i, v := range values {
+				if math.IsNaN(v) {
+					chunk.nulls++
+					continue
+				}
+				levels[i] = 1
+				body = binary.LittleEndian.AppendUint64(body, math.Float64bits(v))
+				min, max = math.Min(min, v), math.Max(max, v)
+			}
+			if chunk.nulls < chunk.values {
+				//the spec wants zero bounds written as -0 for min and +0 for max
+				if min == 0 {
+					min = math.Copysign(0, -1)
+				}
+				if max == 0 {
+					max = 0
+				}
+				chunk.min = binary.LittleEndian.AppendUint64(nil, math.Float64bits(min))
+				chunk.max = binary.LittleEndian.AppendUint64(nil, math.Float64bits(max))
+			}
+			encoded := encodeRLE(levels)
+			page := binary.LittleEndian.AppendUint32(nil, uint32(len(encoded)))
+			page = append(append(page, encoded...), body...)
+			if err := writeParquetPage(out, &chunk, page, codec, true); err != nil {
+				return err
+			}
+			chunks = append(chunks, chunk)
+		}
+		rowGroups = append(rowGroups, chunks)
+	}
+
+	meta := newThriftWriter()
+	meta.i32(1, 1)
+	meta.beginList(2, thriftStruct, len(columns)+2)
+	meta.beginElement()
+	meta.str(4, "schema")
+	meta.i32(5, int32(len(columns)+1))
+	meta.endStruct()
+	meta.beginElement()
+	meta.i32(1, parquetInt64)
+	meta.i32(3, parquetRequired)
+	meta.str(4, parquetIndexColumn)
+	if converted >= 0 {
+		meta.i32(6, converted)
+	}
+	meta.beginStruct(10)
+	meta.beginStruct(8)
+	meta.boolean(1, true)
+	meta.beginStruct(2)
+	meta.beginStruct(unitField)
+	meta.endStruct()
+	meta.endStruct()
+	meta.endStruct()
+	meta.endStruct()
+	meta.endStruct()
+	for _, col := range columns {
+		meta.beginElement()
+		meta.i32(1, parquetDouble)
+		meta.i32(3, parquetOptional)
+		meta.str(4, col)
+		meta.endStruct()
+	}
+	meta.i64(3, int64(ts.Length()))
+	meta.beginList(4, thriftStruct, len(rowGroups))
+	for _, chunks := range rowGroups {
+		var size, compressed int64
+		for _, chunk := range chunks {
+			size += chunk.uncompressed
+			compressed += chunk.compressed
+		}
+		meta.beginElement()
+		meta.beginList(1, thriftStruct, len(chunks))
+		for _, chunk := range chunks {
+			meta.beginElement()
+			meta.i64(2, chunk.offset)
+			meta.beginStruct(3)
+			meta.i32(1, chunk.physical)
+			meta.beginList(2, thriftI32, 2)
+			meta.zigzag(parquetPlain)
+			meta.zigzag(parquetRLE)
+			meta.beginList(3, thriftBinary, 1)
+			meta.varint(uint64(len(chunk.name)))
+			meta.buf.WriteString(chunk.name)
+			meta.i32(4, codec)
+			meta.i64(5, chunk.values)
+			meta.i64(6, chunk.uncompressed)
+			meta.i64(7, chunk.compressed)
+			meta.i64(9, chunk.offset)
+			meta.beginStruct(12)
+			meta.i64(3, chunk.nulls)
+			if chunk.min != nil {
+				meta.binary(5, chunk.max)
+				meta.binary(6, chunk.min)
+			}
+			meta.endStruct()
+			meta.endStruct()
+			meta.endStruct()
+		}
+		meta.i64(2, size)
+		meta.i64(3, chunks[0].values)
+		meta.i64(5, chunks[0].offset)
+		meta.i64(6, compressed)
+		meta.endStruct()
+	}
+	keys := make([]string, 0, len(ts.Meta))
+	for k := range ts.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	timezone := time.UTC.String()
+	if !ts.IsEmpty() {
+		timezone = timezoneName(ts.Start())
+	}
+	meta.beginList(5, thriftStruct, len(keys)+1)
+	for _, k := range append(keys, parquetTimezoneKey) {
+		value := ts.Meta[k]
+		if k == parquetTimezoneKey {
+			value = timezone
+		}
+		meta.beginElement()
+		meta.str(1, k)
+		meta.str(2, value)
+		meta.endStruct()
+	}
+	meta.str(6, "timeseries-go")
+	meta.beginList(7, thriftStruct, len(columns)+1)
+	for i := 0; i <= len(columns); i++ {
+		meta.beginElement()
+		meta.beginStruct(1)
+		meta.endStruct()
+		meta.endStruct()
+	}
+	footer := meta.bytes()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	footer = append(footer, parquetMagic...)
+	_, err := out.Write(footer)
+	return err
+}
+
+//writeParquetPage writes a column chunk as a single v1 data page and fills in its sizes
+func writeParquetPage(out *countingWriter, chunk *parquetChunk, body []byte, codec int32, optional bool) error {
+	page := body
+	if codec == parquetGzip {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(body); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		page = buf.Bytes()
+	}
+	header := newThriftWriter()
+	header.i32(1, parquetDataPage)
+	header.i32(2, int32(len(body)))
+	header.i32(3, int32(len(page)))
+	header.beginStruct(5)
+	header.i32(1, int32(chunk.values))
+	header.i32(2, parquetPlain)
+	header.i32(3, parquetRLE)
+	header.i32(4, parquetRLE)
+	header.endStruct()
+	encoded := header.bytes()
+	chunk.offset = out.n
+	chunk.uncompressed = int64(len(encoded) + len(body))
+	chunk.compressed = int64(len(encoded) + len(page))
+	if _, err := out.Write(encoded); err != nil {
+		return err
+	}
+	_, err := out.Write(page)
+	return err
+}
+
+//encodeRLE encodes levels of bit width 1 as runs of the RLE/bit-packed hybrid encoding
+func encodeRLE(levels []uint64) []byte {
+	var buf []byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		buf = binary.AppendUvarint(buf, uint64(j-i)<<1)
+		buf = append(buf, byte(levels[i]))
+		i = j
+	}
+	return buf
+}
+
+//parquetLeaf is a column of the parquet schema
+type parquetLeaf struct {
+	name       string
+	physical   int64
+	repetition int64
+	converted  int64
+	logical    thriftFields
+}
+
+//timeUnit is the duration of one unit of a timestamp or date column, 0 if the column is neither
+func (l parquetLeaf) timeUnit() time.Duration {
+	if l.physical == parquetInt96 {
+		return time.Nanosecond
+	}
+	if timestamp := l.logical.child(8); timestamp != nil {
+		unit := timestamp.child(2)
+		switch {
+		case unit.child(1) != nil:
+			return time.Millisecond
+		case unit.child(2) != nil:
+			return time.Microsecond
+		case unit.child(3) != nil:
+			return time.Nanosecond
+		}
+	}
+	if l.logical.child(6) != nil {
+		return 24 * time.Hour
+	}
+	switch l.converted {
+	case 6:
+		return 24 * time.Hour
+	case 9:
+		return time.Millisecond
+	case 10:
+		return time.Microsecond
+	}
+	return 0
+}
+
+//numeric tells if the column can be loaded as float64
+func (l parquetLeaf) numeric() bool {
+	switch l.physical {
+	case parquetBoolean, parquetInt32, parquetInt64, parquetFloat, parquetDouble:
+		return true
+	}
+	return false
+}
+
+//toTime converts a value in the column's time unit
+func (l parquetLeaf) toTime(v int64) time.Time {
+	unit := l.timeUnit()
+	if unit == 24*time.Hour {
+		return time.Unix(v*86400, 0).UTC()
+	}
+	return time.Unix(0, v*int64(unit)).UTC()
+}
+
+//parquetValues holds decoded values, ints for integer physical types and floats otherwise
+type parquetValues struct {
+	ints   []int64
+	floats []float64
+}
+
+func (v parquetValues) len() int {
+	if v.ints != nil {
+		return len(v.ints)
+	}
+	return len(v.floats)
+}
+
+//NewTimeSeriesFromParquet reads a parquet file with flat columns, like the ones pandas, Spark or WriteAsParquet write.
+//the index column becomes the Index, numeric columns are loaded as float64 with null as NaN and key-value
+//file metadata is loaded into Meta. pages may be uncompressed, snappy or gzip, with plain or dictionary encoding
+func NewTimeSeriesFromParquet(path string, options ...ParquetReadOptions) (TimeSeries, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return NewTimeSeries(), err
+	}
+	defer f.Close()
+	return NewTimeSeriesFromParquetReader(f, options...)
+}
+
+//NewTimeSeriesFromParquetReader reads parquet from r as NewTimeSeriesFromParquet does. parquet is read from
+//its footer, so r is read into memory unless it is an io.ReaderAt that can Seek, like an *os.File
+func NewTimeSeriesFromParquetReader(r io.Reader, options ...ParquetReadOptions) (TimeSeries, error) {
+	ra, size, err := readerAt(r)
+	if err != nil {
+		return NewTimeSeries(), err
+	}
+	return readParquet(ra, size, options)
+}
+
+func readParquet(r io.ReaderAt, size int64, options []ParquetReadOptions) (TimeSeries, error) {
+	var opts ParquetReadOptions
+	if options != nil {
+		opts = options[0]
+	}
+	ts := NewTimeSeries()
+	tail := make([]byte, 8)
+	if size < 12 {
+		return ts, fmt.Errorf("parquet read failed: file too small")
+	}
+	if _, err := r.ReadAt(tail, size-8); err != nil {
+		return ts, err
+	}
+	footerSize := int64(binary.LittleEndian.Uint32(tail))
+	if string(tail[4:]) != parquetMagic || footerSize > size-12 || footerSize > parquetMaxFooterSize {
+		return ts, fmt.Errorf("parquet read failed: not a parquet file")
+	}
+	footer := make([]byte, footerSize)
+	if _, err := r.ReadAt(footer, size-8-footerSize); err != nil {
+		return ts, err
+	}
+	meta, err := (&thriftReader{data: footer}).readStruct()
+	if err != nil {
+		return ts, fmt.Errorf("parquet read failed: %v", err)
+	}
+
+	schema := meta.list(2)
+	if len(schema) < 2 {
+		return ts, fmt.Errorf("parquet read failed: empty schema")
+	}
+	leaves := make([]parquetLeaf, 0, len(schema)-1)
+	for _, s := range schema[1:] {
+		element, _ := s.(thriftFields)
+		if children, _ := element.int(5); children > 0 {
+			return ts, fmt.Errorf("parquet read failed: nested column %s is not supported", element.str(4))
+		}
+		leaf := parquetLeaf{name: element.str(4), converted: -1, logical: element.child(10)}
+		leaf.physical, _ = element.int(1)
+		leaf.repetition, _ = element.int(3)
+		if converted, ok := element.int(6); ok {
+			leaf.converted = converted
+		}
+		leaves = append(leaves, leaf)
+	}
+	indexColumn := -1
+	for i, leaf := range leaves {
+		if (opts.Index == "" && leaf.timeUnit() != 0) || (opts.Index != "" && leaf.name == opts.Index) {
+			indexColumn = i
+			break
+		}
+	}
+	if indexColumn < 0 || leaves[indexColumn].timeUnit() == 0 {
+		return ts, fmt.Errorf("parquet read failed: no timestamp column %s found for the index", opts.Index)
+	}
+	selected := make([]int, 0)
+	for i, leaf := range leaves {
+		if i == indexColumn {
+			continue
+		}
+		if opts.Columns == nil {
+			if leaf.numeric() && leaf.timeUnit() == 0 && leaf.repetition != parquetRepeated {
+				selected = append(selected, i)
+			}
+		} else if aInB(leaf.name, opts.Columns) {
+			if !leaf.numeric() || leaf.repetition == parquetRepeated {
+				return ts, fmt.Errorf("parquet read failed: column %s is not numeric", leaf.name)
+			}
+			selected = append(selected, i)
+		}
+	}
+	if opts.Columns != nil && len(selected) != len(opts.Columns) {
+		for _, col := range opts.Columns {
+			found := false
+			for _, i := range selected {
+				found = found || leaves[i].name == col
+			}
+			if !found {
+				return ts, fmt.Errorf("parquet read failed: no such column %s", col)
+			}
+		}
+	}
+	for _, i := range selected {
+		ts.Columns[leaves[i].name] = make([]float64, 0)
+	}
+	var location *time.Location
+	for _, kv := range meta.list(5) {
+		pair, _ := kv.(thriftFields)
+		if pair.str(1) == parquetTimezoneKey {
+			location, err = loadTimezone(pair.str(2))
+			if err != nil {
+				logrus.Warnln("parquet read warning: unknown timezone", pair.str(2), "using UTC")
+			}
+			continue
+		}
+		ts.Meta[pair.str(1)] = pair.str(2)
+	}
+
+	index := leaves[indexColumn]
+	for _, rg := range meta.list(4) {
+		group, _ := rg.(thriftFields)
+		chunks := group.list(1)
+		rows, _ := group.int(3)
+		if len(chunks) != len(leaves) {
+			return ts, fmt.Errorf("parquet read failed: row group has %d columns, schema %d", len(chunks), len(leaves))
+		}
+		indexMeta := chunks[indexColumn].(thriftFields).child(3)
+		if min, max, ok := parquetTimeRange(indexMeta.child(12), index); ok {
+			if (!opts.Start.IsZero() && max.Before(opts.Start)) || (!opts.End.IsZero() && min.After(opts.End)) {
+				continue
+			}
+		}
+		values, nulls, err := readParquetChunk(r, indexMeta, index, rows)
+		if err != nil {
+			return ts, err
+		}
+		keep := make([]bool, len(values.ints))
+		for i, v := range values.ints {
+			if nulls[i] {
+				return ts, fmt.Errorf("parquet read failed: null timestamp in index column %s", index.name)
+			}
+			t := index.toTime(v)
+			if (!opts.Start.IsZero() && t.Before(opts.Start)) || (!opts.End.IsZero() && t.After(opts.End)) {
+				continue
+			}
+			keep[i] = true
+			if location != nil {
+				t = t.In(location)
+			}
+			ts.Index = append(ts.Index, t)
+		}
+		for _, c := range selected {
+			values, nulls, err := readParquetChunk(r, chunks[c].(thriftFields).child(3), leaves[c], rows)
+			if err != nil {
+				return ts, err
+			}
+			name := leaves[c].name
+			for i := range keep {
+				if !keep[i] {
+					continue
+				}
+				v := math.NaN()
+				switch {
+				case nulls[i]:
+				case values.ints != nil:
+					v = float64(values.ints[i])
+				default:
+					v = values.floats[i]
+				}
+				ts.Columns[name] = append(ts.Columns[name], v)
+			}
+		}
+	}
+	if !ts.IsEmpty() {
+		ts.changes = append(ts.changes, changelog{"load", ts.End(), ts.Start(), ts.End(), true})
+	}
+	return ts, nil
+}
+
+//parquetTimeRange reads the min and max of a timestamp column from its statistics
+func parquetTimeRange(stats thriftFields, leaf parquetLeaf) (time.Time, time.Time, bool) {
+	min, max := stats.bytes(6), stats.bytes(5)
+	if min == nil || max == nil {
+		min, max = stats.bytes(2), stats.bytes(1)
+	}
+	switch {
+	case leaf.physical == parquetInt64 && len(min) == 8 && len(max) == 8:
+		return leaf.toTime(int64(binary.LittleEndian.Uint64(min))), leaf.toTime(int64(binary.LittleEndian.Uint64(max))), true
+	case leaf.physical == parquetInt32 && len(min) == 4 && len(max) == 4:
+		return leaf.toTime(int64(int32(binary.LittleEndian.Uint32(min)))), leaf.toTime(int64(int32(binary.LittleEndian.Uint32(max)))), true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+//readParquetChunk decodes the rows of a column chunk, nulls marks the rows without a value
+func readParquetChunk(r io.ReaderAt, meta thriftFields, leaf parquetLeaf, rows int64) (parquetValues, []bool, error) {
+	var values parquetValues
+	codec, _ := meta.int(4)
+	start, _ := meta.int(9)
+	if dictionary, ok := meta.int(11); ok && dictionary > 0 && dictionary < start {
+		start = dictionary
+	}
+	length, _ := meta.int(7)
+	if length <= 0 || length > parquetMaxFooterSize*4 {
+		return values, nil, fmt.Errorf("parquet read failed: invalid size %d of column %s", length, leaf.name)
+	}
+	if chunkValues, _ := meta.int(5); rows < 0 || rows > parquetMaxRows || chunkValues != rows {
+		return values, nil, fmt.Errorf("parquet read failed: column %s has %d values in a row group of %d rows", leaf.name, chunkValues, rows)
+	}
+	buf := make([]byte, length)
+	if _, err := r.ReadAt(buf, start); err != nil {
+		return values, nil, err
+	}
+	var dictionary parquetValues
+	var nulls []bool
+	for pos := 0; int64(len(nulls)) < rows && pos < len(buf); {
+		reader := &thriftReader{data: buf[pos:]}
+		header, err := reader.readStruct()
+		if err != nil {
+			return values, nil, fmt.Errorf("parquet read failed: page header of column %s: %v", leaf.name, err)
+		}
+		pos += reader.pos
+		compressedSize, _ := header.int(3)
+		if compressedSize < 0 || int64(pos)+compressedSize > int64(len(buf)) {
+			return values, nil, fmt.Errorf("parquet read failed: truncated page in column %s", leaf.name)
+		}
+		page := buf[pos : pos+int(compressedSize)]
+		pos += int(compressedSize)
+		pageType, _ := header.int(1)
+		uncompressedSize, _ := header.int(2)
+		switch pageType {
+		case parquetDictionaryPage:
+			body, err := parquetDecompress(page, codec, uncompressedSize)
+			if err != nil {
+				return values, nil, err
+			}
+			count, _ := header.child(7).int(1)
+			if dictionary, err = decodePlain(body, leaf.physical, int(count)); err != nil {
+				return values, nil, err
+			}
+		case parquetDataPage:
+			body, err := parquetDecompress(page, codec, uncompressedSize)
+			if err != nil {
+				return values, nil, err
+			}
+			pageHeader := header.child(5)
+			count, _ := pageHeader.int(1)
+			encoding, _ := pageHeader.int(2)
+			if count < 0 || count > rows-int64(len(nulls)) {
+				return values, nil, fmt.Errorf("parquet read failed: page of %d values past the %d rows of column %s", count, rows, leaf.name)
+			}
+			defined := make([]uint64, count)
+			if leaf.repetition == parquetOptional {
+				if len(body) < 4 {
+					return values, nil, fmt.Errorf("parquet read failed: truncated page in column %s", leaf.name)
+				}
+				size := int(binary.LittleEndian.Uint32(body))
+				if size > len(body)-4 {
+					return values, nil, fmt.Errorf("parquet read failed: truncated levels in column %s", leaf.name)
+				}
+				if defined, err = decodeRLE(body[4:4+size], 1, int(count)); err != nil {
+					return values, nil, err
+				}
+				body = body[4+size:]
+			} else {
+				for i := range defined {
+					defined[i] = 1
+				}
+			}
+			if values, nulls, err = appendParquetPage(values, nulls, body, encoding, leaf, defined, dictionary); err != nil {
+				return values, nil, err
+			}
+		case parquetDataPageV2:
+			pageHeader := header.child(8)
+			count, _ := pageHeader.int(1)
+			encoding, _ := pageHeader.int(4)
+			definitionSize, _ := pageHeader.int(5)
+			repetitionSize, _ := pageHeader.int(6)
+			if definitionSize+repetitionSize > int64(len(page)) || definitionSize < 0 || repetitionSize < 0 {
+				return values, nil, fmt.Errorf("parquet read failed: truncated levels in column %s", leaf.name)
+			}
+			if count < 0 || count > rows-int64(len(nulls)) {
+				return values, nil, fmt.Errorf("parquet read failed: page of %d values past the %d rows of column %s", count, rows, leaf.name)
+			}
+			defined := make([]uint64, count)
+			if leaf.repetition == parquetOptional {
+				if defined, err = decodeRLE(page[repetitionSize:repetitionSize+definitionSize], 1, int(count)); err != nil {
+					return values, nil, err
+				}
+			} else {
+				for i := range defined {
+					defined[i] = 1
+				}
+			}
+			body := page[repetitionSize+definitionSize:]
+			if compressed, ok := pageHeader.boolean(7); !ok || compressed {
+				if body, err = parquetDecompress(body, codec, uncompressedSize-repetitionSize-definitionSize); err != nil {
+					return values, nil, err
+				}
+			}
+			if values, nulls, err = appendParquetPage(values, nulls, body, encoding, leaf, defined, dictionary); err != nil {
+				return values, nil, err
+			}
+		}
+	}
+	if int64(len(nulls)) != rows {
+		return values, nil, fmt.Errorf("parquet read failed: column %s has %d rows, want %d", leaf.name, len(nulls), rows)
+	}
+	return values, nulls, nil
+}
+
+//appendParquetPage decodes the values of a data page and appends them, a row whose definition level is 0 is null
+func appendParquetPage(values parquetValues, nulls []bool, body []byte, encoding int64, leaf parquetLeaf, defined []uint64, dictionary parquetValues) (parquetValues, []bool, error) {
+	count := 0
+	for _, d := range defined {
+		if d > 0 {
+			count++
+		}
+	}
+	var decoded parquetValues
+	var err error
+	switch encoding {
+	case parquetPlain:
+		decoded, err = decodePlain(body, leaf.physical, count)
+	case parquetPlainDict, parquetRLEDict:
+		if len(body) == 0 {
+			if count > 0 {
+				return values, nulls, fmt.Errorf("parquet read failed: empty dictionary page in column %s", leaf.name)
+			}
+			break
+		}
+		var indices []uint64
+		indices, err = decodeRLE(body[1:], int(body[0]), count)
+		if err != nil {
+			break
+		}
+		for _, i := range indices {
+			if i >= uint64(dictionary.len()) {
+				return values, nulls, fmt.Errorf("parquet read failed: dictionary index out of range in column %s", leaf.name)
+			}
+			if dictionary.ints != nil {
+				decoded.ints = append(decoded.ints, dictionary.ints[i])
+			} else {
+				decoded.floats = append(decoded.floats, dictionary.floats[i])
+			}
+		}
+	default:
+		return values, nulls, fmt.Errorf("parquet read failed: unsupported encoding %d in column %s", encoding, leaf.name)
+	}
+	if err != nil {
+		return values, nulls, err
+	}
+	next := 0
+	for _, d := range defined {
+		nulls = append(nulls, d == 0)
+		integer := leaf.physical == parquetInt32 || leaf.physical == parquetInt64 || leaf.physical == parquetInt96
+		switch {
+		case integer && d == 0:
+			values.ints = append(values.ints, 0)
+		case integer:
+			values.ints = append(values.ints, decoded.ints[next])
+		case d == 0:
+			values.floats = append(values.floats, math.NaN())
+		default:
+			values.floats = append(values.floats, decoded.floats[next])
+		}
+		if d > 0 {
+			next++
+		}
+	}
+	return values, nulls, nil
+}
+
+//parquetDecompress decompresses a page to size bytes, the uncompressed size its header declares
+func parquetDecompress(page []byte, codec int64, size int64) ([]byte, error) {
+	switch codec {
+	case parquetUncompressed:
+		return page, nil
+	case parquetSnappy:
+		return snappyDecode(page, size)
+	case parquetGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(page))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body, err := ioutil.ReadAll(io.LimitReader(gz, size+1))
+		if err == nil && int64(len(body)) != size {
+			err = fmt.Errorf("parquet read failed: page is not the %d bytes its header declares", size)
+		}
+		return body, err
+	}
+	return nil, fmt.Errorf("parquet read failed: unsupported compression codec %d", codec)
+}
+
+//decodePlain decodes count values of the plain encoding, every value takes at least a bit of body
+func decodePlain(body []byte, physical int64, count int) (parquetValues, error) {
+	var values parquetValues
+	if count < 0 || count > len(body)*8 {
+		return values, fmt.Errorf("parquet read failed: %d values in a page of %d bytes", count, len(body))
+	}
+	size := map[int64]int{parquetInt32: 4, parquetInt64: 8, parquetInt96: 12, parquetFloat: 4, parquetDouble: 8}[physical]
+	if physical == parquetBoolean {
+		values.floats = make([]float64, count)
+		for i := range values.floats {
+			values.floats[i] = float64(body[i/8] >> (i % 8) & 1)
+		}
+		return values, nil
+	}
+	if size == 0 {
+		return values, fmt.Errorf("parquet read failed: unsupported physical type %d", physical)
+	}
+	if len(body) < size*count {
+		return values, fmt.Errorf("parquet read failed: truncated values, %d bytes for %d values", len(body), count)
+	}
+	switch physical {
+	case parquetInt32:
+		values.ints = make([]int64, count)
+		for i := range values.ints {
+			values.ints[i] = int64(int32(binary.LittleEndian.Uint32(body[4*i:])))
+		}
+	case parquetInt64:
+		values.ints = make([]int64, count)
+		for i := range values.ints {
+			values.ints[i] = int64(binary.LittleEndian.Uint64(body[8*i:]))
+		}
+	case parquetInt96: //nanoseconds of the day and julian day, converted to unix nanoseconds
+		values.ints = make([]int64, count)
+		for i := range values.ints {
+			nanos := int64(binary.LittleEndian.Uint64(body[12*i:]))
+			day := int64(binary.LittleEndian.Uint32(body[12*i+8:]))
+			values.ints[i] = (day-parquetJulianEpoch)*int64(24*time.Hour) + nanos
+		}
+	case parquetFloat:
+		values.floats = make([]float64, count)
+		for i := range values.floats {
+			values.floats[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(body[4*i:])))
+		}
+	case parquetDouble:
+		values.floats = make([]float64, count)
+		for i := range values.floats {
+			values.floats[i] = math.Float64frombits(binary.LittleEndian.Uint64(body[8*i:]))
+		}
+	}
+	return values, nil
+}
+
+//decodeRLE decodes count values of the RLE/bit-packed hybrid encoding
+func decodeRLE(data []byte, bitWidth int, count int) ([]uint64, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("parquet read failed: negative count %d of levels or indices", count)
+	}
+	out := make([]uint64, 0, count)
+	if bitWidth == 0 {
+		return make([]uint64, count), nil
+	}
+	if bitWidth > 32 {
+		return nil, fmt.Errorf("parquet read failed: invalid bit width %d", bitWidth)
+	}
+	byteWidth := (bitWidth + 7) / 8
+	for pos := 0; len(out) < count; {
+		if pos >= len(data) {
+			return nil, fmt.Errorf("parquet read failed: truncated levels or indices")
+		}
+		header, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return nil, fmt.Errorf("parquet read failed: invalid run header")
+		}
+		pos += n
+		if header&1 == 0 {
+			if pos+byteWidth > len(data) {
+				return nil, fmt.Errorf("parquet read failed: truncated run")
+			}
+			var v uint64
+			for j := 0; j < byteWidth; j++ {
+				v |= uint64(data[pos+j]) << (8 * j)
+			}
+			pos += byteWidth
+			for j := uint64(0); j < header>>1 && len(out) < count; j++ {
+				out = append(out, v)
+			}
+			continue
+		}
+		groups := int(header >> 1)
+		if groups > len(data) || pos+groups*bitWidth > len(data) {
+			return nil, fmt.Errorf("parquet read failed: truncated bit-packed run")
+		}
+		packed := data[pos : pos+groups*bitWidth]
+		for j := 0; j < groups*8 && len(out) < count; j++ {
+			var v uint64
+			for b := 0; b < bitWidth; b++ {
+				bit := j*bitWidth + b
+				v |= uint64(packed[bit/8]>>(bit%8)&1) << b
+			}
+			out = append(out, v)
+		}
+		pos += groups * bitWidth
+	}
+	return out, nil
+}
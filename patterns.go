@@ -0,0 +1,256 @@
+package timeseries
+
+import (
+	"fmt"
+	"math"
+)
+
+//PatternOptions tunes how candles are classified, ratios are of the candle's high-low range unless noted.
+//the thresholds are pointers so that 0 can be set, nil takes the default and a negative one is an error
+type PatternOptions struct {
+	//DojiBody is the max body of a doji, default 0.1
+	DojiBody *float64
+	//SmallBody is the max body of a small candle like a star or the inside bar of a harami, default 0.3
+	SmallBody *float64
+	//LongBody is the min body of a long candle, default 0.6
+	LongBody *float64
+	//LongShadow is the min shadow as a multiple of the body for hammers and shooting stars, default 2
+	LongShadow *float64
+	//ShortShadow is the max shadow on the other side, default 0.1
+	ShortShadow *float64
+	//Trend is the count of bars compared to tell the trend a candle forms in, default 5 when not positive
+	Trend int
+}
+
+//CandlePatterns lists the patterns Patterns detects, in the order of its columns
+var CandlePatterns = []string{
+	"doji", "dragonfly_doji", "gravestone_doji",
+	"hammer", "hanging_man", "inverted_hammer", "shooting_star",
+	"bullish_engulfing", "bearish_engulfing", "bullish_harami", "bearish_harami",
+	"piercing_line", "dark_cloud_cover", "morning_star", "evening_star",
+	"three_white_soldiers", "three_black_crows",
+}
+
+//candle is a single OHLC bar
+type candle struct {
+	open, high, low, close float64
+}
+
+func (c candle) body() float64       { return math.Abs(c.close - c.open) }
+func (c candle) span() float64       { return c.high - c.low }
+func (c candle) upper() float64      { return c.high - math.Max(c.open, c.close) }
+func (c candle) lower() float64      { return math.Min(c.open, c.close) - c.low }
+func (c candle) bullish() bool       { return c.close > c.open }
+func (c candle) bearish() bool       { return c.close < c.open }
+func (c candle) midpoint() float64   { return (c.open + c.close) / 2 }
+func (c candle) bodyTop() float64    { return math.Max(c.open, c.close) }
+func (c candle) bodyBottom() float64 { return math.Min(c.open, c.close) }
+
+//patternScanner holds the candles and the thresholds of PatternOptions patterns are matched with
+type patternScanner struct {
+	candles     []candle
+	closes      []float64
+	dojiBody    float64
+	smallBody   float64
+	longBody    float64
+	longShadow  float64
+	shortShadow float64
+	trendBars   int
+}
+
+func (p patternScanner) doji(c candle) bool {
+	return c.span() > 0 && c.body() <= p.dojiBody*c.span()
+}
+
+func (p patternScanner) long(c candle) bool {
+	return c.span() > 0 && c.body() >= p.longBody*c.span()
+}
+
+func (p patternScanner) small(c candle) bool {
+	return c.span() > 0 && c.body() <= p.smallBody*c.span()
+}
+
+//hammerShape is a small body on top of a long lower shadow
+func (p patternScanner) hammerShape(c candle) bool {
+	return c.body() > 0 && c.lower() >= p.longShadow*c.body() && c.upper() <= p.shortShadow*c.span()
+}
+
+//invertedShape is a small body under a long upper shadow
+func (p patternScanner) invertedShape(c candle) bool {
+	return c.body() > 0 && c.upper() >= p.longShadow*c.body() && c.lower() <= p.shortShadow*c.span()
+}
+
+//trend before the candle at i, 1 rising, -1 falling, 0 if unknown
+func (p patternScanner) trend(i int) float64 {
+	if i-1-p.trendBars < 0 {
+		return 0
+	}
+	change := p.closes[i-1] - p.closes[i-1-p.trendBars]
+	if change > 0 {
+		return 1
+	}
+	if change < 0 {
+		return -1
+	}
+	return 0
+}
+
+//match tells if the pattern completes at the candle at i
+func (p patternScanner) match(pattern string, i int) bool {
+	c := p.candles[i]
+	var prev, first candle
+	if i >= 1 {
+		prev = p.candles[i-1]
+	}
+	if i >= 2 {
+		first = p.candles[i-2]
+	}
+	switch pattern {
+	case "doji":
+		return p.doji(c)
+	case "dragonfly_doji":
+		return p.doji(c) && c.upper() <= p.shortShadow*c.span()
+	case "gravestone_doji":
+		return p.doji(c) && c.lower() <= p.shortShadow*c.span()
+	case "hammer":
+		return p.hammerShape(c) && p.trend(i) < 0
+	case "hanging_man":
+		return p.hammerShape(c) && p.trend(i) > 0
+	case "inverted_hammer":
+		return p.invertedShape(c) && p.trend(i) < 0
+	case "shooting_star":
+		return p.invertedShape(c) && p.trend(i) > 0
+	}
+	if i < 1 {
+		return false
+	}
+	switch pattern {
+	case "bullish_engulfing":
+		return prev.bearish() && c.bullish() && c.open <= prev.close && c.close >= prev.open && c.body() > prev.body()
+	case "bearish_engulfing":
+		return prev.bullish() && c.bearish() && c.open >= prev.close && c.close <= prev.open && c.body() > prev.body()
+	case "bullish_harami":
+		return prev.bearish() && p.long(prev) && c.bullish() && c.bodyTop() <= prev.open && c.bodyBottom() >= prev.close && c.body() < prev.body()
+	case "bearish_harami":
+		return prev.bullish() && p.long(prev) && c.bearish() && c.bodyTop() <= prev.close && c.bodyBottom() >= prev.open && c.body() < prev.body()
+	case "piercing_line":
+		return prev.bearish() && p.long(prev) && c.bullish() && c.open < prev.close && c.close > prev.midpoint() && c.close < prev.open
+	case "dark_cloud_cover":
+		return prev.bullish() && p.long(prev) && c.bearish() && c.open > prev.close && c.close < prev.midpoint() && c.close > prev.open
+	}
+	if i < 2 {
+		return false
+	}
+	switch pattern {
+	case "morning_star":
+		return first.bearish() && p.long(first) && p.small(prev) && prev.bodyTop() < first.close &&
+			c.bullish() && c.close > first.midpoint()
+	case "evening_star":
+		return first.bullish() && p.long(first) && p.small(prev) && prev.bodyBottom() > first.close &&
+			c.bearish() && c.close < first.midpoint()
+	case "three_white_soldiers":
+		for _, pair := range [][2]candle{{first, prev}, {prev, c}} {
+			before, after := pair[0], pair[1]
+			if !after.bullish() || after.close <= before.close || after.open < before.open || after.open > before.close {
+				return false
+			}
+		}
+		for _, bar := range []candle{first, prev, c} {
+			if !bar.bullish() || !p.long(bar) || bar.upper() > p.shortShadow*bar.span() {
+				return false
+			}
+		}
+		return true
+	case "three_black_crows":
+		for _, pair := range [][2]candle{{first, prev}, {prev, c}} {
+			before, after := pair[0], pair[1]
+			if !after.bearish() || after.close >= before.close || after.open > before.open || after.open < before.close {
+				return false
+			}
+		}
+		for _, bar := range []candle{first, prev, c} {
+			if !bar.bearish() || !p.long(bar) || bar.lower() > p.shortShadow*bar.span() {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+//newPatternScanner reads the OHLC columns and fills in default options
+func (ts TimeSeries) newPatternScanner(options []PatternOptions) (patternScanner, error) {
+	if err := ts.requireColumns("pattern detection", "open", "high", "low", "close"); err != nil {
+		return patternScanner{}, err
+	}
+	var opts PatternOptions
+	if options != nil {
+		opts = options[0]
+	}
+	p := patternScanner{candles: make([]candle, ts.Length()), closes: ts.Columns["close"], trendBars: opts.Trend}
+	if p.trendBars <= 0 {
+		p.trendBars = 5
+	}
+	thresholds := []struct {
+		name     string
+		option   *float64
+		value    *float64
+		fallback float64
+	}{
+		{"DojiBody", opts.DojiBody, &p.dojiBody, 0.1},
+		{"SmallBody", opts.SmallBody, &p.smallBody, 0.3},
+		{"LongBody", opts.LongBody, &p.longBody, 0.6},
+		{"LongShadow", opts.LongShadow, &p.longShadow, 2},
+		{"ShortShadow", opts.ShortShadow, &p.shortShadow, 0.1},
+	}
+	for _, t := range thresholds {
+		*t.value = t.fallback
+		if t.option == nil {
+			continue
+		}
+		if !(*t.option >= 0) {
+			return patternScanner{}, fmt.Errorf("pattern detection failed: %s must not be negative or NaN, got %v", t.name, *t.option)
+		}
+		*t.value = *t.option
+	}
+	for i := range ts.Index {
+		p.candles[i] = candle{ts.Columns["open"][i], ts.Columns["high"][i], ts.Columns["low"][i], ts.Columns["close"][i]}
+	}
+	return p, nil
+}
+
+//Pattern returns for each bar whether the pattern completes on it, to be used with FilterByTruthTable
+//for ex:- ts.FilterByTruthTable(hammers, true). see CandlePatterns for the names
+func (ts TimeSeries) Pattern(name string, options ...PatternOptions) ([]bool, error) {
+	if !aInB(name, CandlePatterns) {
+		return nil, fmt.Errorf("pattern detection failed: no such pattern %s", name)
+	}
+	p, err := ts.newPatternScanner(options)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]bool, ts.Length())
+	for i := range matches {
+		matches[i] = p.match(name, i)
+	}
+	return matches, nil
+}
+
+//Patterns adds a column per pattern in CandlePatterns named cdl_pattern, 1 on the bar a pattern completes and 0 elsewhere
+func (ts TimeSeries) Patterns(options ...PatternOptions) (TimeSeries, error) {
+	p, err := ts.newPatternScanner(options)
+	if err != nil {
+		return ts, err
+	}
+	scanned := ts.shallowCopy()
+	for _, name := range CandlePatterns {
+		scores := make([]float64, ts.Length())
+		for i := range scores {
+			if p.match(name, i) {
+				scores[i] = 1
+			}
+		}
+		scanned.Columns["cdl_"+name] = scores
+	}
+	return scanned, nil
+}
@@ -0,0 +1,87 @@
+package timeseries
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+//Reindex conforms the `TimeSeries` to a new time index, the source index must be sorted
+//method decides values at new timestamps which are not in the source index, default "nan"
+//"nan" leaves them NaN, "ffill" takes the previous row, "bfill" the next row,
+//"nearest" the row closest in time, "linear" interpolates by time between previous and next row
+func (ts TimeSeries) Reindex(index []time.Time, method ...string) (TimeSeries, error) {
+	fillMethod := "nan"
+	if method != nil {
+		fillMethod = method[0]
+	}
+	switch fillMethod {
+	case "nan", "ffill", "bfill", "nearest", "linear":
+	default:
+		return ts, fmt.Errorf("reindex failed: no such fill method %s", fillMethod)
+	}
+	reindexed := NewTimeSeries()
+	reindexed.Index = append(reindexed.Index, index...)
+	reindexed.MaxSize = ts.MaxSize
+	for k, v := range ts.Meta {
+		reindexed.Meta[k] = v
+	}
+	for col := range ts.Columns {
+		reindexed.Columns[col] = make([]float64, 0, len(index))
+	}
+	n := ts.Length()
+	for _, t := range index {
+		j := sort.Search(n, func(i int) bool {
+			return !ts.Index[i].Before(t)
+		})
+		exact := j < n && ts.Index[j].Equal(t)
+		for col, values := range ts.Columns {
+			value := math.NaN()
+			switch {
+			case exact:
+				value = values[j]
+			case fillMethod == "ffill" && j > 0:
+				value = values[j-1]
+			case fillMethod == "bfill" && j < n:
+				value = values[j]
+			case fillMethod == "nearest" && j > 0 && j < n:
+				if ts.Index[j].Sub(t) < t.Sub(ts.Index[j-1]) {
+					value = values[j]
+				} else {
+					value = values[j-1]
+				}
+			case fillMethod == "nearest" && j > 0:
+				value = values[j-1]
+			case fillMethod == "nearest" && j < n:
+				value = values[j]
+			case fillMethod == "linear" && j > 0 && j < n:
+				weight := float64(t.Sub(ts.Index[j-1])) / float64(ts.Index[j].Sub(ts.Index[j-1]))
+				value = values[j-1] + (values[j]-values[j-1])*weight
+			}
+			reindexed.Columns[col] = append(reindexed.Columns[col], value)
+		}
+	}
+	return reindexed, nil
+}
+
+//Asfreq conforms the `TimeSeries` to a regular grid of interval from Start to End.
+//for ex:- Asfreq("1m", "ffill") upsamples 5 minute bars to 1 minute. method as in Reindex
+func (ts TimeSeries) Asfreq(interval string, method ...string) (TimeSeries, error) {
+	if ts.IsEmpty() {
+		return ts, fmt.Errorf("asfreq failed: empty timeseries")
+	}
+	binStart, binEnd, _, err := parseBins(interval, ts.Start())
+	if err != nil {
+		return ts, err
+	}
+	grid := make([]time.Time, 0)
+	t := binStart(ts.Start())
+	if t.Before(ts.Start()) {
+		t = binEnd(t)
+	}
+	for ; !t.After(ts.End()); t = binEnd(t) {
+		grid = append(grid, t)
+	}
+	return ts.Reindex(grid, method...)
+}
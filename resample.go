@@ -0,0 +1,231 @@
+package timeseries
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+//ResampleOptions configures how ResampleWith lays out its bins
+type ResampleOptions struct {
+	//Origin anchors fixed duration bins. either "start" (first timestamp, default),
+	//"start_day" (midnight of the first timestamp), "epoch" (unix epoch), or a time.Time/date string.
+	//calendar intervals always bin by calendar boundary and ignore Origin
+	Origin interface{}
+	//Closed is the side of the bin which is inclusive, "left" (default) or "right"
+	Closed string
+	//Label is the bin edge used as the timestamp of the bar, "left" (default) or "right"
+	Label string
+	//EmptyBins emits a NaN row for bins without any data instead of skipping them
+	EmptyBins bool
+	//Criteria is the column:function map passed to functionMapper, default OHLCV unless Aggregations are set
+	Criteria map[string]string
+	//Aggregations are output columns computed from any number of input columns, added to Criteria
+	Aggregations []Aggregation
+}
+
+//Aggregation computes an output column over each bin from its input columns, either by
+//Function, the name of a criteria function applied on the first input, "vwap" with inputs price and volume,
+//or by Reduce, a custom reducer receiving the bin values (NaN included) of every input keyed by column name
+type Aggregation struct {
+	Output   string
+	Inputs   []string
+	Function string
+	Reduce   func(columns map[string][]float64) float64
+}
+
+//OHLC builds the aggregations of open, high, low and close bars from a single price column
+//for ex:- ResampleOptions{Aggregations: append(OHLC("price"), Aggregation{"volume", []string{"size"}, "sum", nil})}
+func OHLC(column string) []Aggregation {
+	return []Aggregation{
+		{Output: "open", Inputs: []string{column}, Function: "first"},
+		{Output: "high", Inputs: []string{column}, Function: "max"},
+		{Output: "low", Inputs: []string{column}, Function: "min"},
+		{Output: "close", Inputs: []string{column}, Function: "last"},
+	}
+}
+
+//compile checks the aggregation against ts and returns its reducer over a bin
+func (a Aggregation) compile(ts TimeSeries) (func(b bin) float64, error) {
+	if a.Output == "" || len(a.Inputs) == 0 {
+		return nil, fmt.Errorf("couldnt resample: aggregation needs an output and inputs")
+	}
+	if a.Function == "" && a.Reduce == nil {
+		return nil, fmt.Errorf("couldnt resample %s: aggregation needs a Function or a Reduce", a.Output)
+	}
+	for _, col := range a.Inputs {
+		if _, ok := ts.Columns[col]; !ok {
+			return nil, fmt.Errorf("couldnt resample %s: no such column %s", a.Output, col)
+		}
+	}
+	if a.Reduce != nil {
+		return func(b bin) float64 {
+			columns := make(map[string][]float64, len(a.Inputs))
+			for _, col := range a.Inputs {
+				columns[col] = b.values(col)
+			}
+			return a.Reduce(columns)
+		}, nil
+	}
+	if a.Function == "vwap" {
+		if len(a.Inputs) != 2 {
+			return nil, fmt.Errorf("couldnt resample %s: vwap needs inputs price and volume", a.Output)
+		}
+		fn := weightedMean(a.Inputs[1])
+		return func(b bin) float64 {
+			return fn(b, a.Inputs[0])
+		}, nil
+	}
+	fn, err := lookupAggregator(a.Function)
+	if err == nil {
+		err = requireWeightColumn(ts, a.Function)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldnt resample %s by %s: %v", a.Output, a.Function, err)
+	}
+	return func(b bin) float64 {
+		return fn(b, a.Inputs[0])
+	}, nil
+}
+
+//requireWeightColumn errors if function is a wmean:column whose weight column is not in ts
+func requireWeightColumn(ts TimeSeries, function string) error {
+	if !strings.HasPrefix(function, "wmean:") {
+		return nil
+	}
+	column := strings.TrimPrefix(function, "wmean:")
+	if _, ok := ts.Columns[column]; !ok {
+		return fmt.Errorf("no such weight column %s", column)
+	}
+	return nil
+}
+
+//outputs returns the reducer of every output column
+func (options ResampleOptions) outputs(ts TimeSeries) (map[string]func(b bin) float64, error) {
+	outputs := make(map[string]func(b bin) float64)
+	if options.Criteria != nil || options.Aggregations == nil {
+		applyMap, err := functionMapper(options.Criteria)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range options.Criteria {
+			if _, ok := ts.Columns[k]; !ok {
+				continue
+			}
+			if err := requireWeightColumn(ts, v); err != nil {
+				return nil, fmt.Errorf("could not resample field %s by %s: %v", k, v, err)
+			}
+		}
+		for k, fn := range applyMap {
+			if _, ok := ts.Columns[k]; !ok {
+				continue
+			}
+			column, reduce := k, fn
+			outputs[k] = func(b bin) float64 {
+				return reduce(b, column)
+			}
+		}
+	}
+	for _, a := range options.Aggregations {
+		if _, ok := outputs[a.Output]; ok {
+			return nil, fmt.Errorf("couldnt resample: output column %s defined twice", a.Output)
+		}
+		fn, err := a.compile(ts)
+		if err != nil {
+			return nil, err
+		}
+		outputs[a.Output] = fn
+	}
+	return outputs, nil
+}
+
+//origin resolves the Origin option against the first timestamp of the series
+func (options ResampleOptions) origin(first time.Time) (time.Time, error) {
+	switch options.Origin.(type) {
+	case nil:
+		return first, nil
+	case time.Time:
+		return options.Origin.(time.Time), nil
+	case string:
+		switch options.Origin.(string) {
+		case "", "start":
+			return first, nil
+		case "start_day":
+			y, m, d := first.Date()
+			return time.Date(y, m, d, 0, 0, 0, 0, first.Location()), nil
+		case "epoch":
+			return time.Unix(0, 0).In(first.Location()), nil
+		}
+		return parseDate(options.Origin.(string))
+	default:
+		return time.Time{}, fmt.Errorf("invalid type for resample origin `%T`", options.Origin)
+	}
+}
+
+//ResampleWith converts source timeseries interval into different interval using the options provided
+//for ex:- 5m bars aligned to the clock with the bar stamped at its close
+//ts.ResampleWith("5m", ResampleOptions{Origin: "start_day", Closed: "right", Label: "right"})
+func (ts TimeSeries) ResampleWith(interval string, options ResampleOptions) (TimeSeries, error) {
+	if ts.Length() < 2 {
+		return ts, fmt.Errorf("couldnt resample: only %d record found. need min 2", ts.Length())
+	}
+	if (options.Closed != "" && options.Closed != "left" && options.Closed != "right") ||
+		(options.Label != "" && options.Label != "left" && options.Label != "right") {
+		return ts, fmt.Errorf("couldnt resample: closed and label must be left or right")
+	}
+	origin, err := options.origin(ts.Index[0])
+	if err != nil {
+		return ts, err
+	}
+	binStart, binEnd, targetDuration, err := parseBins(interval, origin)
+	if err != nil {
+		return ts, err
+	}
+	outputs, err := options.outputs(ts)
+	if err != nil {
+		return ts, err
+	}
+	sourceDuration := ts.Index[1].Sub(ts.Index[0])
+	if targetDuration < sourceDuration {
+		return ts, fmt.Errorf("couldnt resample: %v is finer than the source interval %v, use Asfreq or Reindex to upsample", interval, sourceDuration)
+	}
+	bucket := binStart
+	if options.Closed == "right" {
+		bucket = func(t time.Time) time.Time {
+			return binStart(t.Add(-time.Nanosecond))
+		}
+	}
+	resampled := NewTimeSeries()
+	emit := func(start time.Time, head, tail int) {
+		if options.Label == "right" {
+			resampled.Index = append(resampled.Index, binEnd(start))
+		} else {
+			resampled.Index = append(resampled.Index, start)
+		}
+		b := bin{ts: ts, head: head, tail: tail, end: binEnd(start)}
+		for k, fn := range outputs {
+			resampled.Columns[k] = append(resampled.Columns[k], fn(b))
+		}
+	}
+	batchHeadIndex := 0
+	current := bucket(ts.Index[0])
+	for i := 1; i <= ts.Length(); i++ {
+		if i == ts.Length() {
+			emit(current, batchHeadIndex, i)
+			break
+		}
+		next := bucket(ts.Index[i])
+		if next.Equal(current) {
+			continue
+		}
+		emit(current, batchHeadIndex, i)
+		if options.EmptyBins {
+			for b := binEnd(current); b.Before(next); b = binEnd(b) {
+				emit(b, i, i)
+			}
+		}
+		current = next
+		batchHeadIndex = i
+	}
+	return resampled, nil
+}
@@ -0,0 +1,315 @@
+package timeseries
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+//Rolling is a moving window over a `TimeSeries`, build it with TimeSeries.Rolling
+//every aggregation returns a copy of the `TimeSeries` with a new column per input column
+//named column_aggregation_window, for ex:- close_mean_20 or close_max_15m
+type Rolling struct {
+	ts         TimeSeries
+	rows       int
+	duration   time.Duration
+	label      string
+	minPeriods int
+	err        error
+}
+
+//Rolling creates a moving window which can be either a row count {int} or a duration {string} like "15m".
+//a duration window at t holds the rows in (t-duration, t]. minPeriods is the min count of non NaN
+//values in a window to produce a value, default the row count for count windows and 1 for duration windows
+func (ts TimeSeries) Rolling(window interface{}, minPeriods ...int) Rolling {
+	r := Rolling{ts: ts}
+	switch window.(type) {
+	case int:
+		r.rows = window.(int)
+		r.label = fmt.Sprint(r.rows)
+		r.minPeriods = r.rows
+		if r.rows <= 0 {
+			r.err = fmt.Errorf("rolling failed: window must be positive not %d", r.rows)
+		}
+	case string:
+		r.duration, r.err = parseInterval(window.(string))
+		r.label = window.(string)
+		r.minPeriods = 1
+		if r.err == nil && r.duration <= 0 {
+			r.err = fmt.Errorf("rolling failed: window must be positive not %s", window.(string))
+		}
+	default:
+		r.err = fmt.Errorf("rolling failed: invalid type for window `%T`", window)
+	}
+	if minPeriods != nil {
+		r.minPeriods = minPeriods[0]
+	}
+	return r
+}
+
+//Mean of the window
+func (r Rolling) Mean(columns ...string) (TimeSeries, error) {
+	return r.aggregate("mean", func() rollingAggregator { return &sumAggregator{mean: true} }, columns)
+}
+
+//Sum of the window
+func (r Rolling) Sum(columns ...string) (TimeSeries, error) {
+	return r.aggregate("sum", func() rollingAggregator { return &sumAggregator{} }, columns)
+}
+
+//Min of the window
+func (r Rolling) Min(columns ...string) (TimeSeries, error) {
+	return r.aggregate("min", func() rollingAggregator {
+		return &dequeAggregator{keep: func(back, v float64) bool { return back < v }}
+	}, columns)
+}
+
+//Max of the window
+func (r Rolling) Max(columns ...string) (TimeSeries, error) {
+	return r.aggregate("max", func() rollingAggregator {
+		return &dequeAggregator{keep: func(back, v float64) bool { return back > v }}
+	}, columns)
+}
+
+//Var is the sample variance of the window
+func (r Rolling) Var(columns ...string) (TimeSeries, error) {
+	return r.aggregate("var", func() rollingAggregator { return &welfordAggregator{} }, columns)
+}
+
+//Std is the sample standard deviation of the window
+func (r Rolling) Std(columns ...string) (TimeSeries, error) {
+	return r.aggregate("std", func() rollingAggregator { return &welfordAggregator{std: true} }, columns)
+}
+
+//Median of the window
+func (r Rolling) Median(columns ...string) (TimeSeries, error) {
+	return r.aggregate("median", func() rollingAggregator { return &sortedAggregator{q: 0.5} }, columns)
+}
+
+//Quantile of the window with q in [0, 1], interpolated linearly between values
+func (r Rolling) Quantile(q float64, columns ...string) (TimeSeries, error) {
+	if q < 0 || q > 1 {
+		return r.ts, fmt.Errorf("rolling failed: quantile must be in [0, 1] not %v", q)
+	}
+	name := fmt.Sprintf("q%g", math.Round(q*1e6)/1e4)
+	return r.aggregate(name, func() rollingAggregator { return &sortedAggregator{q: q} }, columns)
+}
+
+//Apply a custom function on the non NaN values of every window, name is used for the new columns
+func (r Rolling) Apply(name string, fn func([]float64) float64, columns ...string) (TimeSeries, error) {
+	return r.aggregate(name, func() rollingAggregator { return &sliceAggregator{fn: fn} }, columns)
+}
+
+//aggregate runs a fresh aggregator over each column, adding values entering the window
+//and removing values leaving it
+func (r Rolling) aggregate(name string, newAggregator func() rollingAggregator, columns []string) (TimeSeries, error) {
+	if r.err != nil {
+		return r.ts, r.err
+	}
+	if columns == nil {
+		columns = r.ts.ListColumns()
+	}
+	starts := r.windowStarts()
+	rolled := r.ts.shallowCopy()
+	for _, col := range columns {
+		values, ok := r.ts.Columns[col]
+		if !ok {
+			return r.ts, fmt.Errorf("rolling failed: no such column %s", col)
+		}
+		agg := newAggregator()
+		result := make([]float64, len(values))
+		left, count := 0, 0
+		for i, v := range values {
+			if !math.IsNaN(v) {
+				agg.add(i, v)
+				count++
+			}
+			for ; left < starts[i]; left++ {
+				if !math.IsNaN(values[left]) {
+					agg.remove(left, values[left])
+					count--
+				}
+			}
+			if count == 0 || count < r.minPeriods {
+				result[i] = math.NaN()
+			} else {
+				result[i] = agg.value()
+			}
+		}
+		rolled.Columns[col+"_"+name+"_"+r.label] = result
+	}
+	return rolled, nil
+}
+
+//windowStarts returns the index of the first row in the window ending at each row
+func (r Rolling) windowStarts() []int {
+	starts := make([]int, r.ts.Length())
+	left := 0
+	for i, t := range r.ts.Index {
+		if r.duration == 0 {
+			starts[i] = i - r.rows + 1
+			if starts[i] < 0 {
+				starts[i] = 0
+			}
+			continue
+		}
+		for left < i && !r.ts.Index[left].After(t.Add(-r.duration)) {
+			left++
+		}
+		starts[i] = left
+	}
+	return starts
+}
+
+//rollingAggregator is updated as non NaN values enter and leave a window
+type rollingAggregator interface {
+	add(i int, v float64)
+	remove(i int, v float64)
+	value() float64
+}
+
+//sumAggregator keeps a running sum
+type sumAggregator struct {
+	sum  float64
+	n    int
+	mean bool
+}
+
+func (a *sumAggregator) add(i int, v float64) {
+	a.sum += v
+	a.n++
+}
+
+func (a *sumAggregator) remove(i int, v float64) {
+	a.sum -= v
+	a.n--
+	if a.n == 0 {
+		a.sum = 0
+	}
+}
+
+func (a *sumAggregator) value() float64 {
+	if a.mean {
+		return a.sum / float64(a.n)
+	}
+	return a.sum
+}
+
+//welfordAggregator keeps a running mean and sum of squared deviations
+type welfordAggregator struct {
+	n    int
+	mean float64
+	m2   float64
+	std  bool
+}
+
+func (a *welfordAggregator) add(i int, v float64) {
+	a.n++
+	delta := v - a.mean
+	a.mean += delta / float64(a.n)
+	a.m2 += delta * (v - a.mean)
+}
+
+func (a *welfordAggregator) remove(i int, v float64) {
+	if a.n <= 1 {
+		a.n, a.mean, a.m2 = 0, 0, 0
+		return
+	}
+	delta := v - a.mean
+	a.mean -= delta / float64(a.n-1)
+	a.m2 -= delta * (v - a.mean)
+	a.n--
+	if a.m2 < 0 {
+		a.m2 = 0
+	}
+}
+
+func (a *welfordAggregator) value() float64 {
+	if a.n < 2 {
+		return math.NaN()
+	}
+	variance := a.m2 / float64(a.n-1)
+	if a.std {
+		return math.Sqrt(variance)
+	}
+	return variance
+}
+
+//dequeAggregator keeps a monotonic deque of candidates, the front is the min or max of the window
+type dequeAggregator struct {
+	indices []int
+	values  []float64
+	keep    func(back, v float64) bool
+}
+
+func (a *dequeAggregator) add(i int, v float64) {
+	for len(a.values) > 0 && !a.keep(a.values[len(a.values)-1], v) {
+		a.indices = a.indices[:len(a.indices)-1]
+		a.values = a.values[:len(a.values)-1]
+	}
+	a.indices = append(a.indices, i)
+	a.values = append(a.values, v)
+}
+
+func (a *dequeAggregator) remove(i int, v float64) {
+	if len(a.indices) > 0 && a.indices[0] == i {
+		a.indices = a.indices[1:]
+		a.values = a.values[1:]
+	}
+}
+
+func (a *dequeAggregator) value() float64 {
+	return a.values[0]
+}
+
+//sortedAggregator keeps the window sorted for order statistics
+type sortedAggregator struct {
+	sorted []float64
+	q      float64
+}
+
+func (a *sortedAggregator) add(i int, v float64) {
+	at := sort.SearchFloat64s(a.sorted, v)
+	a.sorted = append(a.sorted, 0)
+	copy(a.sorted[at+1:], a.sorted[at:])
+	a.sorted[at] = v
+}
+
+func (a *sortedAggregator) remove(i int, v float64) {
+	at := sort.SearchFloat64s(a.sorted, v)
+	a.sorted = append(a.sorted[:at], a.sorted[at+1:]...)
+}
+
+func (a *sortedAggregator) value() float64 {
+	return quantileOfSorted(a.sorted, a.q)
+}
+
+//sliceAggregator applies a function on the full window
+type sliceAggregator struct {
+	window []float64
+	fn     func([]float64) float64
+}
+
+func (a *sliceAggregator) add(i int, v float64) {
+	a.window = append(a.window, v)
+}
+
+func (a *sliceAggregator) remove(i int, v float64) {
+	a.window = a.window[1:]
+}
+
+func (a *sliceAggregator) value() float64 {
+	return a.fn(a.window)
+}
+
+//quantileOfSorted interpolates linearly between the closest ranks of a sorted array
+func quantileOfSorted(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	position := q * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
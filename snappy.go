@@ -0,0 +1,79 @@
+package timeseries
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+//snappyDecode decompresses a raw snappy block, the format parquet uses for its SNAPPY codec.
+//size is the expected decompressed size, a block claiming any other is rejected before allocating
+func snappyDecode(src []byte, size int64) ([]byte, error) {
+	length, n := binary.Uvarint(src)
+	if n <= 0 || size < 0 || length != uint64(size) {
+		return nil, fmt.Errorf("snappy decode failed: invalid length")
+	}
+	dst := make([]byte, 0, length)
+	for i := n; i < len(src); {
+		tag := src[i]
+		i++
+		var size, offset int
+		switch tag & 0x03 {
+		case 0: //literal
+			size = int(tag >> 2)
+			if size >= 60 {
+				extra := size - 59
+				if i+extra > len(src) {
+					return nil, fmt.Errorf("snappy decode failed: truncated literal")
+				}
+				size = 0
+				for j := 0; j < extra; j++ {
+					size |= int(src[i+j]) << (8 * j)
+				}
+				i += extra
+			}
+			size++
+			if size > len(src)-i || uint64(len(dst)+size) > length {
+				return nil, fmt.Errorf("snappy decode failed: truncated literal")
+			}
+			dst = append(dst, src[i:i+size]...)
+			i += size
+			continue
+		case 1: //copy with a 1 byte offset
+			if i >= len(src) {
+				return nil, fmt.Errorf("snappy decode failed: truncated copy")
+			}
+			size = 4 + int(tag>>2&0x07)
+			offset = int(tag&0xe0)<<3 | int(src[i])
+			i++
+		case 2: //copy with a 2 byte offset
+			if i+2 > len(src) {
+				return nil, fmt.Errorf("snappy decode failed: truncated copy")
+			}
+			size = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[i:]))
+			i += 2
+		case 3: //copy with a 4 byte offset
+			if i+4 > len(src) {
+				return nil, fmt.Errorf("snappy decode failed: truncated copy")
+			}
+			size = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[i:]))
+			i += 4
+		}
+		if offset <= 0 || offset > len(dst) {
+			return nil, fmt.Errorf("snappy decode failed: invalid copy offset %d", offset)
+		}
+		if uint64(len(dst)+size) > length {
+			return nil, fmt.Errorf("snappy decode failed: more than %d bytes", length)
+		}
+		//copies may overlap their own output, so go byte by byte
+		start := len(dst) - offset
+		for j := 0; j < size; j++ {
+			dst = append(dst, dst[start+j])
+		}
+	}
+	if uint64(len(dst)) != length {
+		return nil, fmt.Errorf("snappy decode failed: got %d bytes, want %d", len(dst), length)
+	}
+	return dst, nil
+}
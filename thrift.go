@@ -0,0 +1,295 @@
+package timeseries
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+//thrift compact protocol types, parquet encodes its metadata with them
+const (
+	thriftStop    = 0
+	thriftTrue    = 1
+	thriftFalse   = 2
+	thriftByte    = 3
+	thriftI16     = 4
+	thriftI32     = 5
+	thriftI64     = 6
+	thriftDouble  = 7
+	thriftBinary  = 8
+	thriftList    = 9
+	thriftSet     = 10
+	thriftMap     = 11
+	thriftStruct  = 12
+	thriftMaxSize = 1 << 28
+
+	thriftMaxDepth = 64 //of nested structs and containers, parquet metadata needs a handful
+)
+
+//thriftWriter encodes structs in the thrift compact protocol
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16 //last field id written in each open struct
+}
+
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{last: []int16{0}}
+}
+
+func (w *thriftWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	w.buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func (w *thriftWriter) zigzag(v int64) {
+	w.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	last := &w.last[len(w.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta<<4) | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.zigzag(int64(id))
+	}
+	*last = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.zigzag(int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.zigzag(v)
+}
+
+func (w *thriftWriter) boolean(id int16, v bool) {
+	if v {
+		w.field(id, thriftTrue)
+	} else {
+		w.field(id, thriftFalse)
+	}
+}
+
+func (w *thriftWriter) binary(id int16, b []byte) {
+	w.field(id, thriftBinary)
+	w.varint(uint64(len(b)))
+	w.buf.Write(b)
+}
+
+func (w *thriftWriter) str(id int16, s string) {
+	w.binary(id, []byte(s))
+}
+
+//beginStruct opens a struct field, close it with endStruct
+func (w *thriftWriter) beginStruct(id int16) {
+	w.field(id, thriftStruct)
+	w.last = append(w.last, 0)
+}
+
+//beginElement opens a struct inside a list, close it with endStruct
+func (w *thriftWriter) beginElement() {
+	w.last = append(w.last, 0)
+}
+
+func (w *thriftWriter) endStruct() {
+	w.buf.WriteByte(thriftStop)
+	w.last = w.last[:len(w.last)-1]
+}
+
+//beginList writes a list header, size elements of elementType follow
+func (w *thriftWriter) beginList(id int16, elementType byte, size int) {
+	w.field(id, thriftList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size<<4) | elementType)
+	} else {
+		w.buf.WriteByte(0xf0 | elementType)
+		w.varint(uint64(size))
+	}
+}
+
+//bytes ends the top level struct and returns the encoding
+func (w *thriftWriter) bytes() []byte {
+	w.buf.WriteByte(thriftStop)
+	return w.buf.Bytes()
+}
+
+//thriftFields is a decoded struct, field id to value. values are int64, bool, float64,
+//[]byte, []interface{} or thriftFields
+type thriftFields map[int16]interface{}
+
+func (f thriftFields) int(id int16) (int64, bool) {
+	v, ok := f[id].(int64)
+	return v, ok
+}
+
+func (f thriftFields) bytes(id int16) []byte {
+	v, _ := f[id].([]byte)
+	return v
+}
+
+func (f thriftFields) str(id int16) string {
+	return string(f.bytes(id))
+}
+
+func (f thriftFields) boolean(id int16) (bool, bool) {
+	v, ok := f[id].(bool)
+	return v, ok
+}
+
+func (f thriftFields) list(id int16) []interface{} {
+	v, _ := f[id].([]interface{})
+	return v
+}
+
+func (f thriftFields) child(id int16) thriftFields {
+	v, _ := f[id].(thriftFields)
+	return v
+}
+
+//thriftReader decodes the thrift compact protocol without a schema
+type thriftReader struct {
+	data  []byte
+	pos   int
+	depth int //of the struct or container being read
+}
+
+func (r *thriftReader) byte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, fmt.Errorf("thrift decode failed: unexpected end of data")
+	}
+	r.pos++
+	return r.data[r.pos-1], nil
+}
+
+func (r *thriftReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("thrift decode failed: invalid varint at %d", r.pos)
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *thriftReader) zigzag() (int64, error) {
+	v, err := r.varint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+//readStruct reads fields until the stop field
+func (r *thriftReader) readStruct() (thriftFields, error) {
+	fields := make(thriftFields)
+	var last int16
+	for {
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		typ := header & 0x0f
+		if typ == thriftStop {
+			return fields, nil
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			v, err := r.zigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		last = id
+		if typ == thriftTrue || typ == thriftFalse {
+			fields[id] = typ == thriftTrue
+			continue
+		}
+		fields[id], err = r.readValue(typ)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+//readValue reads a value of typ, booleans inside containers take a byte
+func (r *thriftReader) readValue(typ byte) (interface{}, error) {
+	if typ >= thriftList {
+		if r.depth >= thriftMaxDepth {
+			return nil, fmt.Errorf("thrift decode failed: nested deeper than %d", thriftMaxDepth)
+		}
+		r.depth++
+		defer func() { r.depth-- }()
+	}
+	switch typ {
+	case thriftTrue, thriftFalse:
+		b, err := r.byte()
+		return b == thriftTrue, err
+	case thriftByte:
+		b, err := r.byte()
+		return int64(int8(b)), err
+	case thriftI16, thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftDouble:
+		if r.pos+8 > len(r.data) {
+			return nil, fmt.Errorf("thrift decode failed: unexpected end of data")
+		}
+		r.pos += 8
+		return math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos-8:])), nil
+	case thriftBinary:
+		n, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(r.data)-r.pos) {
+			return nil, fmt.Errorf("thrift decode failed: binary of %d bytes past the end of data", n)
+		}
+		r.pos += int(n)
+		return r.data[r.pos-int(n) : r.pos], nil
+	case thriftList, thriftSet:
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(header >> 4)
+		if size == 15 {
+			if size, err = r.varint(); err != nil {
+				return nil, err
+			}
+		}
+		if size > thriftMaxSize || size > uint64(len(r.data)-r.pos) {
+			return nil, fmt.Errorf("thrift decode failed: list of %d elements", size)
+		}
+		list := make([]interface{}, 0, size)
+		for i := uint64(0); i < size; i++ {
+			v, err := r.readValue(header & 0x0f)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case thriftMap:
+		size, err := r.varint()
+		if err != nil || size == 0 {
+			return nil, err
+		}
+		types, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < size; i++ {
+			if _, err := r.readValue(types >> 4); err != nil {
+				return nil, err
+			}
+			if _, err := r.readValue(types & 0x0f); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case thriftStruct:
+		return r.readStruct()
+	}
+	return nil, fmt.Errorf("thrift decode failed: unknown type %d", typ)
+}
@@ -4,11 +4,11 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
@@ -42,6 +42,21 @@ func (ts TimeSeries) GetIndex() []time.Time {
 	return ts.Index
 }
 
+//shallowCopy returns a `TimeSeries` sharing the column data of ts
+//columns can be added or replaced on the copy without touching ts
+func (ts TimeSeries) shallowCopy() TimeSeries {
+	copied := NewTimeSeries()
+	copied.Index = ts.Index
+	copied.MaxSize = ts.MaxSize
+	for k, v := range ts.Columns {
+		copied.Columns[k] = v
+	}
+	for k, v := range ts.Meta {
+		copied.Meta[k] = v
+	}
+	return copied
+}
+
 //GetDataPointAtIndex returns a DataPoint at the index which can be either {time.Time, int, string}
 func (ts TimeSeries) GetDataPointAtIndex(index interface{}) DataPoint {
 	dp := NewDataPoint()
@@ -107,26 +122,31 @@ func (ts TimeSeries) writeCsv(path string) error {
 	if err != nil {
 		return err
 	}
-	writer := csv.NewWriter(f)
 	defer f.Close()
-	defer writer.Flush()
+	return ts.WriteCSV(f)
+}
+
+//WriteCSV writes the timeseries as csv to w, a timestamp column then all columns
+func (ts TimeSeries) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
 	columns := append([]string{"timestamp"}, ts.ListColumns()...)
 	writer.Write(columns)
 	for i, t := range ts.Index {
 		datapoint := make([]string, 0)
 		datapoint = append(datapoint, t.String()[:len(t.String())-10])
 		for _, col := range columns[1:] {
-			datapoint = append(datapoint, strconv.FormatFloat(ts.Columns[col][i], 'f', 5, 64))
+			datapoint = append(datapoint, formatFloat(ts.Columns[col][i], 5))
 		}
 		writer.Write(datapoint)
 	}
-	return nil
+	writer.Flush()
+	return writer.Error()
 }
 
 func (ts TimeSeries) writeJSON(path string) error {
 	var filename string
 	if path[len(path)-4:] != "json" {
-		filename = filepath.Join(path, ts.Start().String()[:len(ts.Start().String())-10]+" "+ts.End().String()[:len(ts.Start().String())-10]+".csv")
+		filename = filepath.Join(path, ts.Start().String()[:len(ts.Start().String())-10]+" "+ts.End().String()[:len(ts.Start().String())-10]+".json")
 	} else {
 		filename = path
 	}
@@ -136,6 +156,11 @@ func (ts TimeSeries) writeJSON(path string) error {
 	}
 	defer f.Close()
 	defer f.Sync()
+	return ts.WriteJSON(f)
+}
+
+//WriteJSON writes the timeseries as json to w in the split1 schema, timestamp and columns
+func (ts TimeSeries) WriteJSON(w io.Writer) error {
 	data := split1{make([]string, 0), ts.Columns}
 	for _, d := range ts.Index {
 		data.Date = append(data.Date, d.String()[:len(d.String())-10])
@@ -144,8 +169,8 @@ func (ts TimeSeries) writeJSON(path string) error {
 	if err != nil {
 		return err
 	}
-	f.Write(jsonData)
-	return nil
+	_, err = w.Write(jsonData)
+	return err
 }
 
 //WriteAsJSON writes to a folderpath in batches of pagesize. if pagesize not provided
@@ -206,7 +231,7 @@ func (ts TimeSeries) GetWritableCSVBytes(writeColumns bool, columnOrder ...strin
 			if strings.Contains(col, "date") || strings.Contains(col, "time") {
 				datapoint = append(datapoint, t.String()[:len(t.String())-10])
 			} else {
-				datapoint = append(datapoint, strconv.FormatFloat(ts.Columns[col][i], 'f', 4, 64))
+				datapoint = append(datapoint, formatFloat(ts.Columns[col][i], 4))
 			}
 		}
 		buf = append(buf, []byte(strings.Join(datapoint, ",")+"\n")...)
@@ -217,31 +242,36 @@ func (ts TimeSeries) GetWritableCSVBytes(writeColumns bool, columnOrder ...strin
 //AppendToCSV opens `path` as CSV, writes to end, only supports OHLCV
 //it also wont write column names
 func (ts TimeSeries) AppendToCSV(path string, fromIndex ...interface{}) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return ts.AppendToCSVWriter(f, fromIndex...)
+}
+
+//AppendToCSVWriter writes OHLCV rows to w as AppendToCSV does, without column names
+func (ts TimeSeries) AppendToCSVWriter(w io.Writer, fromIndex ...interface{}) error {
 	if fromIndex != nil {
 		var err error
-		ts, err = ts.Slice(fromIndex, ts.Length())
+		ts, err = ts.Slice(fromIndex[0], ts.Length())
 		if err != nil {
 			return err
 		}
 	}
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0755)
-	if err != nil {
-		return err
-	}
-	writer := csv.NewWriter(f)
-	defer f.Close()
-	defer writer.Flush()
+	writer := csv.NewWriter(w)
 	columns := append([]string{"timestamp"}, "open", "high", "low", "close", "volume")
 	writer.Write([]string{})
 	for i, t := range ts.Index {
 		datapoint := make([]string, 0)
 		datapoint = append(datapoint, t.String()[:len(t.String())-10])
 		for _, col := range columns[1:] {
-			datapoint = append(datapoint, strconv.FormatFloat(ts.Columns[col][i], 'f', 4, 64))
+			datapoint = append(datapoint, formatFloat(ts.Columns[col][i], 4))
 		}
 		writer.Write(datapoint)
 	}
-	return nil
+	writer.Flush()
+	return writer.Error()
 }
 
 //AppendDataPointToCSV appends a single datapoint to a csv on disk
@@ -250,18 +280,23 @@ func (ts TimeSeries) AppendDataPointToCSV(path string, dp DataPoint) error {
 	if err != nil {
 		return err
 	}
-	writer := csv.NewWriter(f)
 	defer f.Close()
-	defer writer.Flush()
+	return ts.AppendDataPointToCSVWriter(f, dp)
+}
+
+//AppendDataPointToCSVWriter writes a single OHLCV datapoint to w as AppendDataPointToCSV does
+func (ts TimeSeries) AppendDataPointToCSVWriter(w io.Writer, dp DataPoint) error {
+	writer := csv.NewWriter(w)
 	columns := append([]string{"timestamp"}, "open", "high", "low", "close", "volume")
 	writer.Write([]string{})
 	datapoint := make([]string, 0)
 	datapoint = append(datapoint, dp.Index.String()[:len(dp.Index.String())-10])
 	for _, col := range columns[1:] {
-		datapoint = append(datapoint, strconv.FormatFloat(dp.Columns[col], 'f', 4, 64))
+		datapoint = append(datapoint, formatFloat(dp.Columns[col], 4))
 	}
 	writer.Write(datapoint)
-	return nil
+	writer.Flush()
+	return writer.Error()
 }
 
 //ListColumns returns all numeric columns
@@ -343,7 +378,7 @@ func (ts TimeSeries) Validate(withNonCritical ...bool) error {
 		}
 		isZeroColumn := true
 		for _, j := range ts.Columns[k] {
-			if j != 0 {
+			if j != 0 && !math.IsNaN(j) {
 				isZeroColumn = false
 				break
 			}
@@ -364,7 +399,7 @@ func (ts TimeSeries) Validate(withNonCritical ...bool) error {
 			}
 			isZeroRow := true
 			for col := range ts.Columns {
-				if ts.Columns[col][k] != 0 {
+				if ts.Columns[col][k] != 0 && !math.IsNaN(ts.Columns[col][k]) {
 					isZeroRow = false
 					break
 				}
@@ -376,12 +411,12 @@ func (ts TimeSeries) Validate(withNonCritical ...bool) error {
 		}
 		if len(zeroRows) != 0 {
 			for _, row := range zeroRows {
-				logrus.Warnf("validation warning: row at index %v is empty/all zeroes\n", row)
+				logrus.Warnf("validation warning: row at index %v is empty/all zeroes/NaN\n", row)
 			}
 		}
 		if len(zeroCols) != 0 {
 			for _, col := range zeroCols {
-				logrus.Warnf("validation warning: column %v is empty/all zeroes\n", col)
+				logrus.Warnf("validation warning: column %v is empty/all zeroes/NaN\n", col)
 			}
 		}
 	}
@@ -438,60 +473,33 @@ func (ts TimeSeries) Sort(by ...string) TimeSeries {
 }
 
 //Resample converts source timeseries interval into different interval using criteria provided
+//calendar intervals {"1D", "W-MON", "1M", "1Q", "1Y"} bin by calendar boundary and are labelled by it
+//bins are anchored at the first timestamp, use ResampleWith for other origins and bin edges
 func (ts TimeSeries) Resample(interval string, criteriaMap ...map[string]string) (TimeSeries, error) {
-	if ts.Length() == 1 {
-		return ts, fmt.Errorf("couldnt resample: only one record found. need min 2")
-	}
-	targetDuration, err := parseInterval(interval) //convert string interval to duration
-	sourceDuration := ts.Index[1].Sub(ts.Index[0])
-	var applyMap map[string](func([]float64) float64)
-	Resampledts := NewTimeSeries()
-	if criteriaMap == nil {
-		applyMap, err = functionMapper(nil)
-	} else {
-		applyMap, err = functionMapper(criteriaMap[0])
-	}
-	if err != nil {
-		log.Warnln(err)
-	}
-	if targetDuration < sourceDuration {
-		log.Fatalln("Resample failed: cannot Resample to lower duration %")
-	}
-	var batchHeadIndex, batchTailIndex int
-	for batchTailIndex <= len(ts.Index)-1 {
-		startTime := ts.Index[batchHeadIndex]
-		endTime := startTime.Add(targetDuration)
-		if ts.Index[batchTailIndex].Before(endTime) == true {
-			batchTailIndex++
-		} else {
-			if batchTailIndex == batchHeadIndex {
-				batchTailIndex++
-				batchHeadIndex++
-			} else {
-				Resampledts.Index = append(Resampledts.Index, ts.Index[batchHeadIndex])
-				for k, v := range ts.Columns {
-					Resampledts.Columns[k] = append(Resampledts.Columns[k], applyMap[k](v[batchHeadIndex:batchTailIndex]))
-				}
-				batchHeadIndex = batchTailIndex
-			}
-		}
+	var options ResampleOptions
+	if criteriaMap != nil {
+		options.Criteria = criteriaMap[0]
 	}
-	Resampledts.Index = append(Resampledts.Index, ts.Index[batchHeadIndex])
-	for k, v := range ts.Columns {
-		Resampledts.Columns[k] = append(Resampledts.Columns[k], applyMap[k](v[batchHeadIndex:batchTailIndex]))
-	}
-	return Resampledts, err
+	return ts.ResampleWith(interval, options)
 }
 
 //Split separates by interval. for ex:-Split("1day") would yield an array of `TimeSeries` at day level
+//calendar intervals like Split("1M") split at calendar boundaries
 func (ts TimeSeries) Split(interval string) []TimeSeries {
 	var splitList []TimeSeries
-	duration, _ := parseInterval(interval)
+	if ts.IsEmpty() {
+		return splitList
+	}
+	binStart, binEnd, _, err := parseBins(interval, ts.Index[0])
+	if err != nil {
+		log.Errorln("split failed:", err)
+		return splitList
+	}
 	batchHeadIndex := 0
 	batchTailIndex := 0
 	for batchTailIndex < len(ts.Index) {
-		startTime := ts.Index[batchHeadIndex]
-		endTime := startTime.Add(duration)
+		startTime := binStart(ts.Index[batchHeadIndex])
+		endTime := binEnd(startTime)
 		if ts.Index[batchTailIndex].Before(endTime) == true {
 			batchTailIndex++
 		} else {
@@ -726,11 +734,16 @@ func (ts TimeSeries) Filter(fn func(float64) bool, columns ...string) TimeSeries
 }
 
 //Reduce applies a function continuously on a row to return a single value
+//NaN values are skipped, a column of only NaN reduces to NaN
 func (ts TimeSeries) Reduce(fn func(float64, float64) float64, column string) float64 {
-	reduced := ts.Columns[column][0]
-	for i := range ts.Columns[column] {
+	values := dropNA(ts.Columns[column])
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	reduced := values[0]
+	for i := range values {
 		if i != 0 {
-			reduced = fn(reduced, ts.Columns[column][i])
+			reduced = fn(reduced, values[i])
 		}
 	}
 	return reduced
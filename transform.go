@@ -0,0 +1,157 @@
+package timeseries
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+//TransformOptions selects what the lag and return transforms run on
+//Columns default to all columns. if Suffix is set results are written to column+Suffix
+//instead of overwriting the column
+type TransformOptions struct {
+	Columns []string
+	Suffix  string
+}
+
+//Shift moves data by either a row count {int} or a duration {string, time.Duration}.
+//a row count shifts column values, positive n moves them n rows later, rows left empty are NaN.
+//a duration shifts the Index itself, values stay with their rows and options are ignored.
+//a string duration is as for time.ParseDuration with d (24h) and w (7d) added, for ex:- "-5m", "2h30m", "1w"
+func (ts TimeSeries) Shift(by interface{}, options ...TransformOptions) (TimeSeries, error) {
+	var duration time.Duration
+	switch by.(type) {
+	case int:
+		n := by.(int)
+		return ts.transform("shift", options, func(values []float64) []float64 {
+			shifted := make([]float64, len(values))
+			for i := range shifted {
+				if i-n >= 0 && i-n < len(values) {
+					shifted[i] = values[i-n]
+				} else {
+					shifted[i] = math.NaN()
+				}
+			}
+			return shifted
+		})
+	case string:
+		var err error
+		duration, err = parseShift(by.(string))
+		if err != nil {
+			return ts, fmt.Errorf("shift failed: %v", err)
+		}
+	case time.Duration:
+		duration = by.(time.Duration)
+	default:
+		return ts, fmt.Errorf("shift failed: invalid type for shift `%T`", by)
+	}
+	shifted := ts.shallowCopy()
+	shifted.Index = make([]time.Time, ts.Length())
+	for i, t := range ts.Index {
+		shifted.Index[i] = t.Add(duration)
+	}
+	return shifted, nil
+}
+
+var regexDaysWeeks = regexp.MustCompile(`([0-9]*\.?[0-9]+)([dw])`)
+
+//parseShift parses a signed duration, converting days and weeks to hours for time.ParseDuration
+func parseShift(s string) (time.Duration, error) {
+	var err error
+	hours := regexDaysWeeks.ReplaceAllStringFunc(s, func(match string) string {
+		parts := regexDaysWeeks.FindStringSubmatch(match)
+		n, e := strconv.ParseFloat(parts[1], 64)
+		if e != nil {
+			err = e
+		}
+		if parts[2] == "w" {
+			n *= 7
+		}
+		return strconv.FormatFloat(n*24, 'f', -1, 64) + "h"
+	})
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %s", s)
+	}
+	d, err := time.ParseDuration(hours)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %s", s)
+	}
+	return d, nil
+}
+
+//Diff is the difference of each row with the row n rows earlier, the first n rows are NaN
+func (ts TimeSeries) Diff(n int, options ...TransformOptions) (TimeSeries, error) {
+	return ts.lagged("diff", n, options, func(current, past float64) float64 {
+		return current - past
+	})
+}
+
+//PctChange is the fractional change of each row over the row n rows earlier, the first n rows are NaN
+func (ts TimeSeries) PctChange(n int, options ...TransformOptions) (TimeSeries, error) {
+	return ts.lagged("pctchange", n, options, func(current, past float64) float64 {
+		return current/past - 1
+	})
+}
+
+//LogReturns is the natural log of each row over the previous row, the first row is NaN
+func (ts TimeSeries) LogReturns(options ...TransformOptions) (TimeSeries, error) {
+	return ts.lagged("logreturns", 1, options, func(current, past float64) float64 {
+		return math.Log(current / past)
+	})
+}
+
+//Rebase scales each column so its first non NaN value equals to, for ex:- Rebase(100)
+func (ts TimeSeries) Rebase(to float64, options ...TransformOptions) (TimeSeries, error) {
+	return ts.transform("rebase", options, func(values []float64) []float64 {
+		rebased := make([]float64, len(values))
+		base := math.NaN()
+		for i, v := range values {
+			if math.IsNaN(base) && !math.IsNaN(v) {
+				base = v
+			}
+			rebased[i] = to * v / base
+		}
+		return rebased
+	})
+}
+
+//lagged applies fn on each row and the row n rows earlier, n can be negative to look ahead
+func (ts TimeSeries) lagged(name string, n int, options []TransformOptions, fn func(current, past float64) float64) (TimeSeries, error) {
+	if n == 0 {
+		return ts, fmt.Errorf("%s failed: periods must not be 0", name)
+	}
+	return ts.transform(name, options, func(values []float64) []float64 {
+		result := make([]float64, len(values))
+		for i := range result {
+			if i-n >= 0 && i-n < len(values) {
+				result[i] = fn(values[i], values[i-n])
+			} else {
+				result[i] = math.NaN()
+			}
+		}
+		return result
+	})
+}
+
+//transform applies fn on each column selected by options
+func (ts TimeSeries) transform(name string, options []TransformOptions, fn func([]float64) []float64) (TimeSeries, error) {
+	var opts TransformOptions
+	if options != nil {
+		opts = options[0]
+	}
+	columns := opts.Columns
+	if columns == nil {
+		columns = ts.ListColumns()
+	}
+	transformed := ts.shallowCopy()
+	for _, col := range columns {
+		values, ok := ts.Columns[col]
+		if !ok {
+			return ts, fmt.Errorf("%s failed: no such column %s", name, col)
+		}
+		transformed.Columns[col+opts.Suffix] = fn(values)
+	}
+	return transformed, nil
+}
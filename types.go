@@ -5,11 +5,12 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
 	"math"
 	"os"
-	"path/filepath"
-	"strconv"
+	"path"
 	"strings"
 	"time"
 
@@ -32,43 +33,43 @@ type TableSetter interface {
 
 //yahoo for data from yahoo finance
 type yahoo struct {
-	Date   []string  `json:"Date" csv:"Date"`
-	Open   []float64 `json:"Open" csv:"Open"`
-	High   []float64 `json:"High" csv:"High"`
-	Low    []float64 `json:"Low" csv:"Low"`
-	Close  []float64 `json:"Close" csv:"Close"`
-	Volume []float64 `json:"Volume" csv:"Volume"`
-	OI     []float64 `json:"OI" csv:"OI"`
-	IV     []float64 `json:"IV" csv:"IV"`
+	Date   []string `json:"Date" csv:"Date"`
+	Open   nanArray `json:"Open" csv:"Open"`
+	High   nanArray `json:"High" csv:"High"`
+	Low    nanArray `json:"Low" csv:"Low"`
+	Close  nanArray `json:"Close" csv:"Close"`
+	Volume nanArray `json:"Volume" csv:"Volume"`
+	OI     nanArray `json:"OI" csv:"OI"`
+	IV     nanArray `json:"IV" csv:"IV"`
 }
 
 //Generic tohlcv
 type generic struct {
-	Date   []string  `json:"timestamp" csv:"timestamp"`
-	Open   []float64 `json:"open" csv:"pen"`
-	High   []float64 `json:"high" csv:"igh"`
-	Low    []float64 `json:"low" csv:"low"`
-	Close  []float64 `json:"close" csv:"close"`
-	Volume []float64 `json:"volume" csv:"volume"`
-	OI     []float64 `json:"OI" csv:"OI"`
-	IV     []float64 `json:"IV" csv:"IV"`
+	Date   []string `json:"timestamp" csv:"timestamp"`
+	Open   nanArray `json:"open" csv:"pen"`
+	High   nanArray `json:"high" csv:"igh"`
+	Low    nanArray `json:"low" csv:"low"`
+	Close  nanArray `json:"close" csv:"close"`
+	Volume nanArray `json:"volume" csv:"volume"`
+	OI     nanArray `json:"OI" csv:"OI"`
+	IV     nanArray `json:"IV" csv:"IV"`
 }
 
 //split for nested column json
 type split1 struct {
-	Date    []string             `json:"timestamp"`
-	Columns map[string][]float64 `json:"columns"`
+	Date    []string   `json:"timestamp"`
+	Columns nanColumns `json:"columns"`
 }
 
 //split0 same as split but different name
 type split0 struct {
-	Date    []string             `json:"TimeIndex"`
-	Columns map[string][]float64 `json:"Columns"`
+	Date    []string   `json:"TimeIndex"`
+	Columns nanColumns `json:"Columns"`
 }
 
 type split struct {
-	Date    []string             `json:"index"`
-	Columns map[string][]float64 `json:"columns"`
+	Date    []string   `json:"index"`
+	Columns nanColumns `json:"columns"`
 }
 
 //DataPoint holds a single point of data
@@ -124,24 +125,37 @@ func NewTimeSeriesFromGetter(ts ...TableGetter) TimeSeries {
 
 //NewTimeSeriesFromCSV reads a CSV file, if offset is provided, those many bytes are read from EOF
 func NewTimeSeriesFromCSV(filepath string, offset ...int64) (TimeSeries, error) {
-	stat, err := os.Stat(filepath)
+	file, err := os.Open(filepath)
 	if err != nil {
 		return TimeSeries{}, err
 	}
-	logrus.Infof("Total file size: %v KB", stat.Size()/1024)
-	if offset == nil || float64(stat.Size()) < math.Abs(float64(offset[0])) {
-		logrus.Infof("Reading entire file: %v KB", stat.Size()/1024)
-		return NewTimeSeriesFromFile(filepath)
+	defer file.Close()
+	return NewTimeSeriesFromCSVReadSeeker(file, offset...)
+}
+
+//NewTimeSeriesFromCSVReadSeeker reads a CSV as NewTimeSeriesFromCSV does, if offset is provided,
+//those many bytes are read from the end
+func NewTimeSeriesFromCSVReadSeeker(file io.ReadSeeker, offset ...int64) (TimeSeries, error) {
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return TimeSeries{}, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return TimeSeries{}, err
+	}
+	logrus.Infof("Total file size: %v KB", size/1024)
+	if offset == nil || float64(size) < math.Abs(float64(offset[0])) {
+		logrus.Infof("Reading entire file: %v KB", size/1024)
+		return NewTimeSeriesFromReader(file, "csv")
 	}
 	logrus.Infof("Reading last %v KB...", math.Abs(float64(offset[0]))/1024)
 	ts := NewTimeSeries()
-	file, _ := os.Open(filepath)
 
 	columnNames, err := csv.NewReader(file).Read()
 	if offset[0] > 0 {
 		offset[0] = -offset[0]
 	}
-	file.Seek(offset[0], 2)
+	file.Seek(offset[0], io.SeekEnd)
 	eol := make([]byte, 0) //find end of line
 	for !bytes.Contains(eol, []byte("\n")) {
 		b := make([]byte, 1)
@@ -169,10 +183,7 @@ func NewTimeSeriesFromCSV(filepath string, offset ...int64) (TimeSeries, error)
 				}
 				ts.Index = append(ts.Index, datapoint)
 			} else {
-				if columns[i][j] == "" {
-					columns[i][j] = "0"
-				}
-				datapoint, err := strconv.ParseFloat(columns[i][j], 64)
+				datapoint, err := parseFloat(columns[i][j])
 				if err != nil {
 					logrus.Errorln("float parse failed while loading timeseries from file at index ", i, j, err)
 				}
@@ -184,8 +195,29 @@ func NewTimeSeriesFromCSV(filepath string, offset ...int64) (TimeSeries, error)
 }
 
 //NewTimeSeriesFromFile reads a json or csv file.
-//schema types yahoo, generic
+//schema types yahoo, generic. their OI and IV fields are loaded as columns oi and iv when present
 func NewTimeSeriesFromFile(filepath string, sourceSchema ...string) (TimeSeries, error) {
+	f, err := os.Open(filepath)
+	if err != nil {
+		return NewTimeSeries(), err
+	}
+	defer f.Close()
+	return NewTimeSeriesFromReader(f, fileFormat(filepath), sourceSchema...)
+}
+
+//fileFormat is csv or json from the extension of a path, empty for others
+func fileFormat(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".csv"):
+		return "csv"
+	case strings.HasSuffix(name, "json"):
+		return "json"
+	}
+	return ""
+}
+
+//NewTimeSeriesFromReader reads json or csv, per format, from r as NewTimeSeriesFromFile does
+func NewTimeSeriesFromReader(r io.Reader, format string, sourceSchema ...string) (TimeSeries, error) {
 	var schema string
 	ts := NewTimeSeries()
 	if sourceSchema == nil {
@@ -193,22 +225,10 @@ func NewTimeSeriesFromFile(filepath string, sourceSchema ...string) (TimeSeries,
 	} else {
 		schema = sourceSchema[0]
 	}
-	if filepath[len(filepath)-4:] == ".csv" {
-		schema = "auto"
-	}
-	file, err := ioutil.ReadFile(filepath)
-	if err != nil {
-		return NewTimeSeries(), err
-	}
 
-	switch filepath[len(filepath)-4:] {
-	case ".csv":
-		f, err := os.Open(filepath)
-		defer f.Close()
-		if err != nil {
-			return ts, err
-		}
-		csvdata := csv.NewReader(f)
+	switch format {
+	case "csv":
+		csvdata := csv.NewReader(r)
 		columnNames, err := csvdata.Read()
 		var indexCol int
 		for index, col := range columnNames {
@@ -231,10 +251,7 @@ func NewTimeSeriesFromFile(filepath string, sourceSchema ...string) (TimeSeries,
 					}
 					ts.Index = append(ts.Index, datapoint)
 				} else {
-					if columns[i][j] == "" {
-						columns[i][j] = "0"
-					}
-					datapoint, err := strconv.ParseFloat(columns[i][j], 64)
+					datapoint, err := parseFloat(columns[i][j])
 					if err != nil {
 						logrus.Errorln("float parse failed while loading timeseries from file at index ", i, j, err)
 					}
@@ -244,6 +261,10 @@ func NewTimeSeriesFromFile(filepath string, sourceSchema ...string) (TimeSeries,
 		}
 
 	case "json":
+		file, err := ioutil.ReadAll(r)
+		if err != nil {
+			return ts, err
+		}
 		if schema == "yahoo" {
 			var data yahoo
 			json.Unmarshal(file, &data)
@@ -257,6 +278,7 @@ func NewTimeSeriesFromFile(filepath string, sourceSchema ...string) (TimeSeries,
 			ts.Columns["low"] = data.Low
 			ts.Columns["close"] = data.Close
 			ts.Columns["volume"] = data.Volume
+			ts.loadOptional(map[string][]float64{"oi": data.OI, "iv": data.IV})
 		} else if schema == "generic" {
 			var data generic
 			json.Unmarshal(file, &data)
@@ -270,7 +292,7 @@ func NewTimeSeriesFromFile(filepath string, sourceSchema ...string) (TimeSeries,
 			ts.Columns["low"] = data.Low
 			ts.Columns["close"] = data.Close
 			ts.Columns["volume"] = data.Volume
-
+			ts.loadOptional(map[string][]float64{"oi": data.OI, "iv": data.IV})
 		} else if schema == "split" {
 			var data split
 			json.Unmarshal(file, &data)
@@ -305,33 +327,31 @@ func NewTimeSeriesFromFile(filepath string, sourceSchema ...string) (TimeSeries,
 		return ts, fmt.Errorf("load failed, probably wrong schema provided")
 	}
 	ts.changes = append(ts.changes, changelog{"load", ts.End(), ts.Start(), ts.End(), true})
-	// for col := range ts.Columns {
-	// 	allzero := true
-	// 	for i := 0; i < len(ts.Columns[col]); i++ {
-	// 		if ts.Columns[col][i] != 0 {
-	// 			allzero = false
-	// 		}
-	// 	}
-	// 	if allzero {
-	// 		continue
-	// 	}
-	// 	for i := 0; i < len(ts.Columns[col])-2; {
-	// 		if ts.Columns[col][i+1] == 0 && col != "volume" {
-	// 			substitute := ts.Columns[col][i]
-	// 			for ts.Columns[col][i+1] == 0 {
-	// 				ts.Columns[col][i+1] = substitute
-	// 				i++
-	// 			}
-	// 		}
-	// 		i++
-	// 	}
-	//}
 	return ts, nil
 }
 
+//loadOptional adds the columns a schema declares but a file may leave out, like OI and IV
+//columns missing from the file are skipped, partially filled ones are padded with NaN
+func (ts *TimeSeries) loadOptional(columns map[string][]float64) {
+	for name, data := range columns {
+		if len(data) == 0 {
+			continue
+		}
+		for len(data) < ts.Length() {
+			data = append(data, math.NaN())
+		}
+		ts.Columns[name] = data[:ts.Length()]
+	}
+}
+
 //NewTimeSeriesFromDirectory reads entire directory
 func NewTimeSeriesFromDirectory(directory string, sourceSchema ...string) (TimeSeries, error) {
-	files, err := ioutil.ReadDir(directory)
+	return NewTimeSeriesFromFS(os.DirFS(directory), ".", sourceSchema...)
+}
+
+//NewTimeSeriesFromFS reads the json and csv files of directory in fsys, like an embed.FS, as NewTimeSeriesFromDirectory does
+func NewTimeSeriesFromFS(fsys fs.FS, directory string, sourceSchema ...string) (TimeSeries, error) {
+	files, err := fs.ReadDir(fsys, directory)
 	if err != nil {
 		return NewTimeSeries(), err
 	}
@@ -343,11 +363,16 @@ func NewTimeSeriesFromDirectory(directory string, sourceSchema ...string) (TimeS
 	}
 	ts := NewTimeSeries()
 	for _, f := range files {
-		fullpath := filepath.Join(directory, f.Name())
-		if fullpath[len(fullpath)-4:] != "json" && fullpath[len(fullpath)-3:] != "csv" {
+		format := fileFormat(f.Name())
+		if f.IsDir() || format == "" {
 			continue
 		}
-		presentRead, err := NewTimeSeriesFromFile(fullpath, schema)
+		file, err := fsys.Open(path.Join(directory, f.Name()))
+		if err != nil {
+			return NewTimeSeries(), err
+		}
+		presentRead, err := NewTimeSeriesFromReader(file, format, schema)
+		file.Close()
 		if err != nil {
 			return NewTimeSeries(), err
 		}
@@ -1,7 +1,9 @@
 package timeseries
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"regexp"
@@ -54,6 +56,22 @@ func isFloat(num string) bool {
 	return true
 }
 
+//parseFloat parses a numeric cell, empty cells are missing values and load as NaN
+func parseFloat(num string) (float64, error) {
+	if strings.TrimSpace(num) == "" {
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(strings.TrimSpace(num), 64)
+}
+
+//formatFloat formats a numeric cell, NaN is written as an empty cell
+func formatFloat(num float64, prec int) string {
+	if math.IsNaN(num) {
+		return ""
+	}
+	return strconv.FormatFloat(num, 'f', prec, 64)
+}
+
 //ParseDate parses datetime
 //Rules: dates must be delimited by "-"
 //times with : RFC3339
@@ -105,6 +123,7 @@ var regexDuration, _ = regexp.Compile("[0-9]+[a-zA-Z]{1}")
 //ParseInterval can be minute, hour, day
 //If absolute is set, it wont parse or check. Just direct convert.
 //Max is 1 week, because month is not rigorously defined.
+//Calendar units like month are parsed by parseCalendarInterval instead.
 func parseInterval(interval string, absolute ...bool) (time.Duration, error) {
 	if absolute != nil {
 		return time.ParseDuration(interval)
@@ -152,11 +171,14 @@ func listFilesInDir(dirname string) []string {
 	return files
 }
 
-//FunctionMapper maps a string:string map to a string:func map.
-//the func here is a numerical reduce function which takes a float64 array and returns a single value
-//available functions: min, max, sd, first, last, sum, mean ::: which must be passed as string keys
-func functionMapper(criteria map[string]string) (map[string]func(arr []float64) float64, error) {
-	applyMap := make(map[string]func([]float64) float64)
+//FunctionMapper maps a string:string map to a string:aggregator map.
+//the aggregator here is a numerical reduce function over a bin of rows which returns a single value
+//NaN values are skipped, a bin of only NaN reduces to NaN (count and nunique to 0)
+//available functions: first, last, sum, min, max, mean, median, sd, var, range, count, nunique,
+//quantiles like q95, weighted mean by another column like wmean:volume, time weighted mean twmean,
+//and anything added with RegisterAggregator ::: which must be passed as string keys
+func functionMapper(criteria map[string]string) (map[string]aggregator, error) {
+	applyMap := make(map[string]aggregator)
 	if criteria == nil {
 		criteria = map[string]string{
 			"open":   "first",
@@ -168,69 +190,69 @@ func functionMapper(criteria map[string]string) (map[string]func(arr []float64)
 	}
 
 	for k, v := range criteria {
-		switch v {
-		case "first":
-			applyMap[k] = func(arr []float64) float64 {
-				return arr[0]
-			}
-		case "last":
-			applyMap[k] = func(arr []float64) float64 {
-				return arr[len(arr)-1]
-			}
-		case "sum":
-			applyMap[k] = func(arr []float64) float64 {
-				s := 0.0
-				for _, value := range arr {
-					s = s + value
-				}
-				return s
-			}
-		case "max":
-			applyMap[k] = func(arr []float64) float64 {
-				maximum := arr[0]
-				for _, value := range arr {
-					if value > maximum {
-						maximum = value
-					}
-				}
-				return maximum
+		fn, err := lookupAggregator(v)
+		if err != nil {
+			return nil, fmt.Errorf("could not resample field %s by %s: %v", k, v, err)
+		}
+		applyMap[k] = fn
+	}
+
+	return applyMap, nil
+}
+
+//readerAt gives the random access the parquet and binary readers need to what remains of r. a file or other
+//io.ReaderAt that can Seek is used in place and its offset is left where it was, anything else is read into memory
+func readerAt(r io.Reader) (io.ReaderAt, int64, error) {
+	if ra, ok := r.(io.ReaderAt); ok {
+		if s, ok := r.(io.Seeker); ok {
+			offset, err := s.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, 0, err
 			}
-		case "min":
-			applyMap[k] = func(arr []float64) float64 {
-				minimum := arr[0]
-				for _, value := range arr {
-					if value < minimum {
-						minimum = value
-					}
-				}
-				return minimum
+			size, err := s.Seek(0, io.SeekEnd)
+			if err != nil {
+				return nil, 0, err
 			}
-		case "mean":
-			applyMap[k] = func(arr []float64) float64 {
-				sum := 0.0
-				for _, value := range arr {
-					sum += value
-				}
-				return sum / float64(len(arr))
+			if _, err := s.Seek(offset, io.SeekStart); err != nil {
+				return nil, 0, err
 			}
-		case "sd": //standard deviation
-			applyMap[k] = func(arr []float64) float64 {
-				sum := 0.0
-				for _, value := range arr {
-					sum += value
-				}
-				mean := sum / float64(len(arr))
-				stddev := 0.0
-				for _, value := range arr {
-					stddev += (value - mean)
-				}
-				stddev = math.Sqrt(stddev / float64(len(arr)))
-				return stddev
+			return io.NewSectionReader(ra, offset, size-offset), size - offset, nil
+		}
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+//timezoneName names the timezone of t for a file, the IANA name if it has one, else its offset like +05:30.
+//zones built by time.FixedZone or parsed from an offset have an empty or made up name, and Local means nothing to a reader
+func timezoneName(t time.Time) string {
+	name := t.Location().String()
+	if name != "" && name != "Local" {
+		if location, err := time.LoadLocation(name); err == nil {
+			_, offset := t.Zone()
+			if _, loaded := t.In(location).Zone(); loaded == offset {
+				return name
 			}
-		default:
-			return nil, fmt.Errorf("could not resample field %s by %s: no such field or function", k, v)
 		}
 	}
+	return t.Format("-07:00")
+}
 
-	return applyMap, nil
+//loadTimezone loads what timezoneName wrote, an IANA name or an offset, UTC if it is empty or unknown
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if t, err := time.Parse("-07:00", name); err == nil {
+		_, offset := t.Zone()
+		return time.FixedZone(name, offset), nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC, err
+	}
+	return location, nil
 }